@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// keygen writes a new private key for JWT signing into the keys directory.
+// Point JWT_ACTIVE_KID at the printed key ID to start signing with it.
+func main() {
+	dir := flag.String("dir", os.Getenv("JWT_KEYS_DIR"), "directory holding the signing keys")
+	alg := flag.String("alg", "EdDSA", "key algorithm: EdDSA or RS256")
+	kid := flag.String("kid", time.Now().UTC().Format("20060102-150405"), "key ID")
+	flag.Parse()
+
+	if *dir == "" {
+		log.Fatal("Set -dir or JWT_KEYS_DIR")
+	}
+
+	var key interface{}
+	var err error
+	switch *alg {
+	case "EdDSA":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case "RS256":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		log.Fatalf("Unsupported algorithm %q", *alg)
+	}
+	if err != nil {
+		log.Fatal("Failed to generate key:", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		log.Fatal("Failed to encode key:", err)
+	}
+
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		log.Fatal("Failed to create keys directory:", err)
+	}
+
+	path := filepath.Join(*dir, *kid+".pem")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Fatal("Failed to create key file:", err)
+	}
+	defer f.Close()
+
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		log.Fatal("Failed to write key:", err)
+	}
+
+	log.Printf("🔑 Wrote %s key %s to %s", *alg, *kid, path)
+}
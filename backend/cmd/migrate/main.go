@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"expense-tracker/internal/database"
+	"expense-tracker/internal/jobs"
 	"expense-tracker/internal/models"
 
 	"gorm.io/gorm"
@@ -18,16 +19,67 @@ func main() {
 
 	log.Println("🔄 Starting database migration...")
 
+	converted, err := jobs.MigrateMoneyColumns(db)
+	if err != nil {
+		log.Fatal("Failed to migrate money columns:", err)
+	}
+	for _, table := range converted {
+		log.Printf("💰 Converted %s.amount to minor units", table)
+	}
+
 	// Auto migrate the schema
 	err = db.AutoMigrate(
 		&models.User{},
+		&models.Workspace{},
+		&models.WorkspaceMember{},
 		&models.Category{},
 		&models.Transaction{},
+		&models.Contact{},
+		&models.TransactionSplit{},
+		&models.Settlement{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.PasswordResetToken{},
+		&models.EmailVerificationToken{},
+		&models.RecoveryCode{},
+		&models.LoginThrottle{},
+		&models.APIToken{},
+		&models.SecurityEvent{},
+		&models.Identity{},
+		&models.OIDCState{},
+		&models.WebAuthnCredential{},
+		&models.WebAuthnSession{},
+		&models.ProofOfWorkRedemption{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	migrated, err := jobs.MigratePersonalWorkspaces(db)
+	if err != nil {
+		log.Fatal("Failed to migrate personal workspaces:", err)
+	}
+	if migrated > 0 {
+		log.Printf("🏠 Created personal workspaces for %d user(s)", migrated)
+	}
+
+	payers, err := jobs.MigrateTransactionPayers(db)
+	if err != nil {
+		log.Fatal("Failed to migrate transaction payers:", err)
+	}
+	if payers > 0 {
+		log.Printf("💸 Recorded payers for %d transaction(s)", payers)
+	}
+
+	verified, err := jobs.MigrateEmailVerification(db)
+	if err != nil {
+		log.Fatal("Failed to migrate email verification:", err)
+	}
+	if verified > 0 {
+		log.Printf("📧 Marked %d existing user(s) as verified", verified)
+	}
+
 	log.Println("✅ Database migration completed successfully!")
 
 	// Seed default categories
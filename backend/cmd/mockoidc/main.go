@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"expense-tracker/internal/oidc/mockoidc"
+)
+
+// mockoidc serves the mock OpenID Connect provider of internal/oidc/mockoidc
+// for local development:
+//
+//	go run cmd/mockoidc/main.go -addr :9999
+//
+// then configure OIDC_PROVIDERS=mock, OIDC_MOCK_ISSUER=http://localhost:9999,
+// OIDC_MOCK_CLIENT_ID=expense-tracker and OIDC_MOCK_CLIENT_SECRET=secret.
+func main() {
+	addr := flag.String("addr", ":9999", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9999", "issuer URL as seen by clients")
+	clientID := flag.String("client-id", "expense-tracker", "accepted client ID")
+	clientSecret := flag.String("client-secret", "secret", "accepted client secret")
+	flag.Parse()
+
+	p, err := mockoidc.New(*issuer, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatal("Failed to generate signing key:", err)
+	}
+
+	log.Printf("🧪 Mock OIDC provider %s listening on %s", *issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, p.Handler()))
+}
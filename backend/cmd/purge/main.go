@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/jobs"
+)
+
+// purge hard-deletes accounts whose deletion grace period has ended. The
+// server does this periodically too; this command is for running it from cron.
+func main() {
+	db, err := database.Connect()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	purged, err := jobs.PurgeDeletedAccounts(db)
+	if err != nil {
+		log.Fatal("Failed to purge deleted accounts:", err)
+	}
+
+	log.Printf("🗑️  Purged %d deleted account(s)", purged)
+}
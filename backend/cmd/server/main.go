@@ -3,14 +3,16 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"expense-tracker/internal/database"
-	"expense-tracker/internal/handlers"
-	"expense-tracker/internal/middleware"
+	"expense-tracker/internal/jobs"
+	"expense-tracker/internal/mailer"
 	"expense-tracker/internal/models"
+	"expense-tracker/internal/oidc"
+	"expense-tracker/internal/passkey"
+	"expense-tracker/pkg/utils"
 
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
@@ -26,67 +28,74 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	converted, err := jobs.MigrateMoneyColumns(db)
+	if err != nil {
+		log.Fatal("Failed to migrate money columns:", err)
+	}
+	for _, table := range converted {
+		log.Printf("💰 Converted %s.amount to minor units", table)
+	}
+
 	// Auto-migrate models
-	if err := db.AutoMigrate(&models.User{}, &models.Category{}, &models.Transaction{}); err != nil {
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Workspace{},
+		&models.WorkspaceMember{},
+		&models.Category{},
+		&models.Transaction{},
+		&models.Contact{},
+		&models.TransactionSplit{},
+		&models.Settlement{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.PasswordResetToken{},
+		&models.EmailVerificationToken{},
+		&models.RecoveryCode{},
+		&models.LoginThrottle{},
+		&models.APIToken{},
+		&models.SecurityEvent{},
+		&models.Identity{},
+		&models.OIDCState{},
+		&models.WebAuthnCredential{},
+		&models.WebAuthnSession{},
+		&models.ProofOfWorkRedemption{},
+	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	if _, err := jobs.MigratePersonalWorkspaces(db); err != nil {
+		log.Fatal("Failed to migrate personal workspaces:", err)
+	}
+	if _, err := jobs.MigrateTransactionPayers(db); err != nil {
+		log.Fatal("Failed to migrate transaction payers:", err)
+	}
+	if _, err := jobs.MigrateEmailVerification(db); err != nil {
+		log.Fatal("Failed to migrate email verification:", err)
+	}
+
 	log.Println("✅ Database connected and migrated successfully!")
 
-	// Initialize Gin router
-	router := gin.Default()
-
-	// CORS configuration
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:3000"} // Frontend URL
-	config.AllowCredentials = true
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	router.Use(cors.New(config))
-
-	// Public routes (no authentication required)
-	auth := router.Group("/api/auth")
-	{
-		auth.POST("/register", handlers.Register)
-		auth.POST("/login", handlers.Login)
+	// JWT signing keys
+	if err := utils.LoadKeys(); err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
 	}
 
-	// Protected routes (authentication required)
-	api := router.Group("/api")
-	api.Use(middleware.AuthMiddleware())
-	{
-		// User routes
-		api.GET("/me", handlers.GetMe)
-
-		// Categories routes
-		api.GET("/categories", handlers.GetCategories)
-		api.GET("/categories/:id", handlers.GetCategory)
-		api.POST("/categories", handlers.CreateCategory)
-		api.PUT("/categories/:id", handlers.UpdateCategory)
-		api.DELETE("/categories/:id", handlers.DeleteCategory)
-
-		// Transactions routes
-		api.GET("/transactions", handlers.GetTransactions)
-		api.GET("/transactions/:id", handlers.GetTransaction)
-		api.POST("/transactions", handlers.CreateTransaction)
-		api.PUT("/transactions/:id", handlers.UpdateTransaction)
-		api.DELETE("/transactions/:id", handlers.DeleteTransaction)
-		
-		// Reports routes
-		api.GET("/reports/monthly", handlers.GetMonthlyReport)
-		api.GET("/dashboard", handlers.GetDashboardStats)
-
-		// Transactions routes (will be implemented later)
-		// api.GET("/transactions", handlers.GetTransactions)
-		// api.POST("/transactions", handlers.CreateTransaction)
-		// api.PUT("/transactions/:id", handlers.UpdateTransaction)
-		// api.DELETE("/transactions/:id", handlers.DeleteTransaction)
-		// api.GET("/transactions/report", handlers.GetMonthlyReport)
+	// Hard-delete accounts whose deletion grace period has ended
+	jobs.StartPurgeLoop(db, time.Hour)
+
+	// Outgoing email
+	mailer.Default = mailer.NewFromEnv()
+
+	// External identity providers
+	oidc.LoadProviders()
+
+	// Passkeys (WebAuthn relying party)
+	if passkey.Default, err = passkey.NewFromEnv(); err != nil {
+		log.Fatal("Invalid WebAuthn configuration:", err)
 	}
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	router := newRouter()
 
 	// Get port from environment or use default
 	port := os.Getenv("PORT")
@@ -99,4 +108,4 @@ func main() {
 	if err := router.Run(":" + port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
-}
\ No newline at end of file
+}
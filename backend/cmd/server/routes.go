@@ -0,0 +1,211 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/handlers"
+	"expense-tracker/internal/middleware"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/ratelimit"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// newRouter builds the HTTP router with every route and its middleware.
+func newRouter() *gin.Engine {
+	router := gin.Default()
+
+	// Only honour X-Forwarded-For from known proxies, otherwise clients could
+	// spoof their IP and dodge per-IP limits.
+	var trustedProxies []string
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		trustedProxies = strings.Split(proxies, ",")
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
+	// CORS configuration
+	config := cors.DefaultConfig()
+	config.AllowOrigins = []string{"http://localhost:3000"} // Frontend URL
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		config.AllowOrigins = strings.Split(origins, ",")
+	}
+	// Credentials are required for the HttpOnly auth cookies (AUTH_COOKIES)
+	config.AllowCredentials = true
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", auth.CSRFHeader, middleware.WorkspaceHeader}
+	config.ExposeHeaders = []string{middleware.ImpersonatedByHeader, middleware.ImpersonationModeHeader}
+	router.Use(cors.New(config))
+
+	// Rate limits per route group
+	limiter := ratelimit.NewMemoryStore()
+	authLimit := loadRateLimit("RATE_LIMIT_AUTH", "20/1m")
+	apiLimit := loadRateLimit("RATE_LIMIT_API", "300/1m")
+
+	// Public routes (no authentication required)
+	authRoutes := router.Group("/api/auth")
+	authRoutes.Use(middleware.RateLimit("auth", authLimit, limiter))
+	{
+		authRoutes.GET("/pow/challenge", handlers.GetProofOfWorkChallenge)
+		authRoutes.POST("/register", handlers.Register)
+		authRoutes.POST("/login", handlers.Login)
+		authRoutes.POST("/refresh", handlers.Refresh)
+		authRoutes.POST("/logout", middleware.AuthMiddleware(), middleware.RejectAPITokens(), handlers.Logout)
+		authRoutes.POST("/forgot-password", handlers.ForgotPassword)
+		authRoutes.POST("/reset-password", handlers.ResetPassword)
+		authRoutes.POST("/verify-email", handlers.VerifyEmail)
+		authRoutes.POST("/resend-verification", middleware.AuthMiddleware(), middleware.RejectAPITokens(), middleware.RejectImpersonatedWrites(), handlers.ResendVerification)
+		authRoutes.POST("/mfa/verify", handlers.VerifyMFA)
+		authRoutes.POST("/magic-link", handlers.RequestMagicLink)
+		authRoutes.POST("/magic-link/verify", handlers.VerifyMagicLink)
+		authRoutes.GET("/oidc/providers", handlers.GetOIDCProviders)
+		authRoutes.GET("/oidc/:provider", handlers.StartOIDCLogin)
+		authRoutes.GET("/oidc/:provider/callback", handlers.OIDCCallback)
+		authRoutes.POST("/oidc/exchange", handlers.OIDCExchange)
+		authRoutes.POST("/passkey/login/begin", handlers.BeginPasskeyLogin)
+		authRoutes.POST("/passkey/login/finish", handlers.FinishPasskeyLogin)
+	}
+
+	// Protected routes (authentication required)
+	api := router.Group("/api")
+	api.Use(middleware.AuthMiddleware(), middleware.RateLimit("api", apiLimit, limiter), middleware.LimitImpersonation())
+
+	// Account management needs an interactive login; API tokens are rejected
+	// and impersonating admins can only look
+	account := api.Group("")
+	account.Use(middleware.RejectAPITokens(), middleware.RejectImpersonatedWrites())
+	{
+		// User routes (reachable before the email is verified)
+		account.GET("/me", handlers.GetMe)
+		account.PATCH("/me", handlers.UpdateMe)
+		account.POST("/me/password", handlers.ChangePassword)
+		account.POST("/me/email", handlers.RequestEmailChange)
+		account.GET("/me/export", handlers.ExportMe)
+		account.DELETE("/me", handlers.DeleteMe)
+		account.GET("/me/security-events", handlers.GetSecurityEvents)
+
+		// Linked identity provider accounts
+		account.GET("/me/identities", handlers.GetIdentities)
+		account.POST("/me/identities/:provider", handlers.StartOIDCLink)
+		account.DELETE("/me/identities/:id", handlers.DeleteIdentity)
+
+		// Passkeys
+		account.GET("/me/passkeys", handlers.GetPasskeys)
+		account.POST("/me/passkeys/register/begin", handlers.BeginPasskeyRegistration)
+		account.POST("/me/passkeys/register/finish", handlers.FinishPasskeyRegistration)
+		account.DELETE("/me/passkeys/:id", handlers.DeletePasskey)
+
+		// Two-factor authentication
+		account.POST("/me/mfa/totp/setup", handlers.SetupTOTP)
+		account.POST("/me/mfa/totp/confirm", handlers.ConfirmTOTP)
+		account.DELETE("/me/mfa/totp", handlers.DisableTOTP)
+		account.POST("/me/mfa/recovery-codes", handlers.RegenerateRecoveryCodes)
+
+		// Sessions routes
+		account.GET("/sessions", handlers.GetSessions)
+		account.DELETE("/sessions/:id", handlers.DeleteSession)
+
+		// Personal access tokens
+		account.GET("/tokens", handlers.GetAPITokens)
+		account.POST("/tokens", handlers.CreateAPIToken)
+		account.DELETE("/tokens/:id", handlers.DeleteAPIToken)
+
+		// Workspaces (shared ledgers)
+		account.GET("/workspaces", handlers.GetWorkspaces)
+		account.POST("/workspaces", handlers.CreateWorkspace)
+		account.GET("/workspaces/:id", handlers.GetWorkspace)
+		account.PATCH("/workspaces/:id", handlers.UpdateWorkspace)
+		account.DELETE("/workspaces/:id", handlers.DeleteWorkspace)
+		account.POST("/workspaces/:id/members", handlers.AddWorkspaceMember)
+		account.PATCH("/workspaces/:id/members/:userId", handlers.UpdateWorkspaceMember)
+		account.DELETE("/workspaces/:id/members/:userId", handlers.RemoveWorkspaceMember)
+	}
+
+	// Protected routes that also require a verified email when
+	// REQUIRE_EMAIL_VERIFICATION is enabled. API tokens need the matching scope.
+	// They work on the workspace selected with ?workspace_id= or
+	// X-Workspace-ID (the personal one by default); viewers can only read.
+	verified := api.Group("")
+	verified.Use(middleware.RequireVerifiedEmail(), middleware.ResolveWorkspace())
+	{
+		// Categories routes
+		verified.GET("/categories", middleware.RequireScope(models.ScopeCategoriesRead), handlers.GetCategories)
+		verified.GET("/categories/:id", middleware.RequireScope(models.ScopeCategoriesRead), handlers.GetCategory)
+		verified.POST("/categories", middleware.RequireScope(models.ScopeCategoriesWrite), middleware.RequireWorkspaceRole(models.WorkspaceEditor), handlers.CreateCategory)
+		verified.PUT("/categories/:id", middleware.RequireScope(models.ScopeCategoriesWrite), middleware.RequireWorkspaceRole(models.WorkspaceEditor), handlers.UpdateCategory)
+		verified.DELETE("/categories/:id", middleware.RequireScope(models.ScopeCategoriesWrite), middleware.RequireWorkspaceRole(models.WorkspaceEditor), handlers.DeleteCategory)
+
+		// Transactions routes
+		verified.GET("/transactions", middleware.RequireScope(models.ScopeTransactionsRead), handlers.GetTransactions)
+		verified.GET("/transactions/:id", middleware.RequireScope(models.ScopeTransactionsRead), handlers.GetTransaction)
+		verified.POST("/transactions", middleware.RequireScope(models.ScopeTransactionsWrite), middleware.RequireWorkspaceRole(models.WorkspaceEditor), handlers.CreateTransaction)
+		verified.PUT("/transactions/:id", middleware.RequireScope(models.ScopeTransactionsWrite), middleware.RequireWorkspaceRole(models.WorkspaceEditor), handlers.UpdateTransaction)
+		verified.DELETE("/transactions/:id", middleware.RequireScope(models.ScopeTransactionsWrite), middleware.RequireWorkspaceRole(models.WorkspaceEditor), handlers.DeleteTransaction)
+
+		// Split expenses: contacts, balances and settlements
+		verified.GET("/contacts", middleware.RequireScope(models.ScopeTransactionsRead), handlers.GetContacts)
+		verified.POST("/contacts", middleware.RequireScope(models.ScopeTransactionsWrite), middleware.RequireWorkspaceRole(models.WorkspaceEditor), handlers.CreateContact)
+		verified.DELETE("/contacts/:id", middleware.RequireScope(models.ScopeTransactionsWrite), middleware.RequireWorkspaceRole(models.WorkspaceEditor), handlers.DeleteContact)
+		verified.GET("/balances", middleware.RequireScope(models.ScopeTransactionsRead), handlers.GetBalances)
+		verified.GET("/settlements", middleware.RequireScope(models.ScopeTransactionsRead), handlers.GetSettlements)
+		verified.POST("/settlements", middleware.RequireScope(models.ScopeTransactionsWrite), middleware.RequireWorkspaceRole(models.WorkspaceEditor), handlers.CreateSettlement)
+
+		// Reports routes
+		verified.GET("/reports/monthly", middleware.RequireScope(models.ScopeReportsRead), handlers.GetMonthlyReport)
+		verified.GET("/dashboard", middleware.RequireScope(models.ScopeReportsRead), handlers.GetDashboardStats)
+
+		// Transactions routes (will be implemented later)
+		// api.GET("/transactions", handlers.GetTransactions)
+		// api.POST("/transactions", handlers.CreateTransaction)
+		// api.PUT("/transactions/:id", handlers.UpdateTransaction)
+		// api.DELETE("/transactions/:id", handlers.DeleteTransaction)
+		// api.GET("/transactions/report", handlers.GetMonthlyReport)
+	}
+
+	// Admin routes
+	admin := api.Group("/admin")
+	admin.Use(middleware.RejectAPITokens(), middleware.RequireRole(models.RoleAdmin))
+	{
+		admin.GET("/users", handlers.AdminGetUsers)
+		admin.GET("/users/:id", handlers.AdminGetUser)
+		admin.POST("/users/:id/disable", handlers.AdminDisableUser)
+		admin.POST("/users/:id/enable", handlers.AdminEnableUser)
+		admin.POST("/users/:id/impersonate", handlers.AdminImpersonateUser)
+		admin.GET("/security-events", handlers.AdminGetSecurityEvents)
+
+		// System categories (shared by every user)
+		admin.GET("/categories", handlers.AdminGetCategories)
+		admin.POST("/categories", handlers.AdminCreateCategory)
+		admin.PUT("/categories/:id", handlers.AdminUpdateCategory)
+		admin.DELETE("/categories/:id", handlers.AdminDeleteCategory)
+	}
+
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", handlers.GetJWKS)
+
+	// Health check
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": "ok"})
+	})
+
+	return router
+}
+
+// loadRateLimit reads a "N/duration" limit from the environment, falling back
+// to the given default. "off" disables the limit.
+func loadRateLimit(key, fallback string) ratelimit.Limit {
+	value := os.Getenv(key)
+	if value == "" {
+		value = fallback
+	}
+
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return limit
+}
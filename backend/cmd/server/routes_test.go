@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/testdb"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("JWT_SECRET", "test-secret")
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// createAPIToken stores a personal access token for the user the way
+// CreateAPIToken does and returns the raw value.
+func createAPIToken(t *testing.T, userID uint, scopes string, expiresAt, revokedAt *time.Time) string {
+	t.Helper()
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	raw := models.APITokenPrefix + secret
+
+	token := models.APIToken{
+		UserID:      userID,
+		Name:        "test",
+		TokenHash:   utils.HashToken(raw),
+		TokenPrefix: raw[:len(models.APITokenPrefix)+4],
+		Scopes:      scopes,
+		ExpiresAt:   expiresAt,
+		RevokedAt:   revokedAt,
+	}
+	if err := database.DB.Create(&token).Error; err != nil {
+		t.Fatalf("create API token: %v", err)
+	}
+	return raw
+}
+
+// request sends an authenticated request through the router and returns the
+// status code.
+func request(router http.Handler, method, path, token, body string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestReadOnlyAPITokenCannotWrite(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "reader@example.com")
+	token := createAPIToken(t, user.ID, models.ScopeTransactionsRead, nil, nil)
+	router := newRouter()
+
+	if code := request(router, http.MethodGet, "/api/transactions", token, ""); code != http.StatusOK {
+		t.Fatalf("GET /api/transactions = %d, want %d", code, http.StatusOK)
+	}
+
+	tests := []struct {
+		method, path, body string
+	}{
+		// Write routes need the matching write scope
+		{http.MethodPost, "/api/transactions", `{"amount":10,"type":"expense","description":"x"}`},
+		{http.MethodPut, "/api/transactions/1", `{"amount":10}`},
+		{http.MethodDelete, "/api/transactions/1", ""},
+		{http.MethodPost, "/api/contacts", `{"name":"Bob"}`},
+		{http.MethodPost, "/api/settlements", `{}`},
+		{http.MethodPost, "/api/categories", `{"name":"Food"}`},
+		// Reads outside the token's scopes
+		{http.MethodGet, "/api/categories", ""},
+		{http.MethodGet, "/api/reports/monthly", ""},
+		// Account and admin routes need an interactive login
+		{http.MethodGet, "/api/me", ""},
+		{http.MethodPatch, "/api/me", `{"name":"Mallory"}`},
+		{http.MethodPost, "/api/me/password", `{}`},
+		{http.MethodGet, "/api/tokens", ""},
+		{http.MethodPost, "/api/tokens", `{"name":"escalated","scopes":["transactions:write"]}`},
+		{http.MethodGet, "/api/sessions", ""},
+		{http.MethodPost, "/api/workspaces", `{"name":"Shared"}`},
+		{http.MethodPost, "/api/auth/logout", ""},
+		{http.MethodGet, "/api/admin/users", ""},
+	}
+	for _, tt := range tests {
+		if code := request(router, tt.method, tt.path, token, tt.body); code != http.StatusForbidden {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, code, http.StatusForbidden)
+		}
+	}
+
+	var count int64
+	database.DB.Model(&models.APIToken{}).Count(&count)
+	if count != 1 {
+		t.Errorf("%d API tokens after the requests, want 1", count)
+	}
+}
+
+func TestExpiredOrRevokedAPITokenIsRejected(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "reader@example.com")
+	router := newRouter()
+
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	tokens := map[string]string{
+		"expired": createAPIToken(t, user.ID, models.ScopeTransactionsRead, &past, nil),
+		"revoked": createAPIToken(t, user.ID, models.ScopeTransactionsRead, &future, &past),
+	}
+	for name, token := range tokens {
+		if code := request(router, http.MethodGet, "/api/transactions", token, ""); code != http.StatusUnauthorized {
+			t.Errorf("%s token: GET /api/transactions = %d, want %d", name, code, http.StatusUnauthorized)
+		}
+	}
+
+	valid := createAPIToken(t, user.ID, models.ScopeTransactionsRead, &future, nil)
+	if code := request(router, http.MethodGet, "/api/transactions", valid, ""); code != http.StatusOK {
+		t.Errorf("valid token: GET /api/transactions = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestSessionTokenReachesAccountRoutes(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "owner@example.com")
+	router := newRouter()
+
+	token, err := utils.GenerateToken(user.ID, user.Email, user.Role, 0)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	for _, path := range []string{"/api/me", "/api/tokens", "/api/categories", "/api/transactions"} {
+		if code := request(router, http.MethodGet, path, token, ""); code != http.StatusOK {
+			t.Errorf("GET %s = %d, want %d", path, code, http.StatusOK)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+)
+
+// setrole changes a user's role, e.g. to create the first admin. Existing
+// access tokens are invalidated so clients refresh and pick up the new role.
+func main() {
+	email := flag.String("email", "", "email of the user to update")
+	role := flag.String("role", models.RoleAdmin, "new role (user or admin)")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+	if *role != models.RoleUser && *role != models.RoleAdmin {
+		log.Fatalf("Unknown role %q", *role)
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	var user models.User
+	if err := db.Where("email = ?", *email).First(&user).Error; err != nil {
+		log.Fatal("User not found:", err)
+	}
+
+	if err := db.Model(&user).Updates(map[string]interface{}{
+		"role":               *role,
+		"tokens_valid_after": time.Now(),
+	}).Error; err != nil {
+		log.Fatal("Failed to update role:", err)
+	}
+
+	log.Printf("✅ %s is now %s", user.Email, *role)
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"log"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Record appends a security event about userID (0 when unknown) with the
+// client IP and user agent of the request. Failures are logged, never
+// returned, so auditing cannot break the action being audited.
+func Record(c *gin.Context, eventType string, userID uint, details string) {
+	event := newEvent(c, eventType, userID, details)
+	save(&event)
+}
+
+// RecordAdmin appends a security event for an action the requesting admin
+// took on the account targetID.
+func RecordAdmin(c *gin.Context, eventType string, targetID uint, details string) {
+	event := newEvent(c, eventType, targetID, details)
+	if actorID, ok := c.Get("userID"); ok {
+		id := actorID.(uint)
+		event.ActorID = &id
+	}
+	save(&event)
+}
+
+// RecordActor appends a security event about targetID for an action taken
+// by actorID, such as an admin impersonating the account.
+func RecordActor(c *gin.Context, eventType string, targetID, actorID uint, details string) {
+	event := newEvent(c, eventType, targetID, details)
+	event.ActorID = &actorID
+	save(&event)
+}
+
+func newEvent(c *gin.Context, eventType string, userID uint, details string) models.SecurityEvent {
+	event := models.SecurityEvent{
+		Type:      eventType,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Details:   details,
+	}
+	if userID != 0 {
+		event.UserID = &userID
+	}
+	return event
+}
+
+func save(event *models.SecurityEvent) {
+	if err := database.DB.Create(event).Error; err != nil {
+		log.Printf("⚠️  Failed to record security event %s: %v", event.Type, err)
+	}
+}
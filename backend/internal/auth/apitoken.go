@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+)
+
+// apiTokenTouchInterval limits how often LastUsedAt is written.
+const apiTokenTouchInterval = time.Minute
+
+var ErrInvalidAPIToken = errors.New("invalid or expired API token")
+
+// AuthenticateAPIToken looks up a personal access token and returns it with
+// its user when it is neither revoked nor expired.
+func AuthenticateAPIToken(raw string) (*models.APIToken, *models.User, error) {
+	var token models.APIToken
+	if err := database.DB.Where("token_hash = ? AND revoked_at IS NULL", utils.HashToken(raw)).
+		First(&token).Error; err != nil {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, token.UserID).Error; err != nil || user.DisabledAt != nil {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > apiTokenTouchInterval {
+		database.DB.Model(&token).UpdateColumn("last_used_at", now)
+	}
+
+	return &token, &user, nil
+}
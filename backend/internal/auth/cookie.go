@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cookie names used when AUTH_COOKIES is enabled.
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFTokenCookie    = "csrf_token"
+
+	// CSRFHeader must echo the csrf_token cookie on state-changing requests
+	// authenticated by cookie (double-submit).
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// CookieAuthEnabled reports whether tokens are handed out as HttpOnly cookies
+// instead of in the response body.
+func CookieAuthEnabled() bool {
+	return os.Getenv("AUTH_COOKIES") == "true"
+}
+
+type cookieOptions struct {
+	domain   string
+	secure   bool
+	sameSite http.SameSite
+}
+
+func loadCookieOptions() cookieOptions {
+	opts := cookieOptions{
+		domain:   os.Getenv("COOKIE_DOMAIN"),
+		secure:   os.Getenv("COOKIE_SECURE") != "false",
+		sameSite: http.SameSiteLaxMode,
+	}
+
+	switch strings.ToLower(os.Getenv("COOKIE_SAMESITE")) {
+	case "strict":
+		opts.sameSite = http.SameSiteStrictMode
+	case "none":
+		opts.sameSite = http.SameSiteNoneMode
+		if !opts.secure {
+			log.Println("⚠️  COOKIE_SAMESITE=none requires Secure cookies, ignoring COOKIE_SECURE=false")
+			opts.secure = true
+		}
+	}
+
+	return opts
+}
+
+// SetAuthCookies stores the token pair in HttpOnly cookies together with a
+// fresh CSRF token, which is returned so it can also go in the response body.
+func SetAuthCookies(c *gin.Context, accessToken, refreshToken string) (string, error) {
+	csrfToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	opts := loadCookieOptions()
+	refreshTTL := utils.RefreshTokenTTL()
+
+	setCookie(c, opts, AccessTokenCookie, accessToken, "/api", utils.AccessTokenTTL(), true)
+	setCookie(c, opts, RefreshTokenCookie, refreshToken, "/api/auth", refreshTTL, true)
+	// Readable by JavaScript so the frontend can echo it in CSRFHeader.
+	setCookie(c, opts, CSRFTokenCookie, csrfToken, "/", refreshTTL, false)
+
+	return csrfToken, nil
+}
+
+// ClearAuthCookies removes the cookies set by SetAuthCookies.
+func ClearAuthCookies(c *gin.Context) {
+	opts := loadCookieOptions()
+
+	setCookie(c, opts, AccessTokenCookie, "", "/api", -1, true)
+	setCookie(c, opts, RefreshTokenCookie, "", "/api/auth", -1, true)
+	setCookie(c, opts, CSRFTokenCookie, "", "/", -1, false)
+}
+
+func setCookie(c *gin.Context, opts cookieOptions, name, value, path string, ttl time.Duration, httpOnly bool) {
+	maxAge := int(ttl.Seconds())
+	if ttl < 0 {
+		maxAge = -1
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   opts.domain,
+		MaxAge:   maxAge,
+		Secure:   opts.secure,
+		HttpOnly: httpOnly,
+		SameSite: opts.sameSite,
+	})
+}
+
+// ValidCSRF checks the double-submit token of a cookie-authenticated request.
+// Safe methods are always allowed.
+func ValidCSRF(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	cookie, err := c.Cookie(CSRFTokenCookie)
+	if err != nil || cookie == "" {
+		return false
+	}
+
+	header := c.GetHeader(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
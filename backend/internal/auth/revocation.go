@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+)
+
+// RevocationStore keeps track of access tokens that were revoked before they
+// expired.
+type RevocationStore interface {
+	Revoke(jti string, userID uint, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+}
+
+// Revocations is the store used by AuthMiddleware and Logout. It defaults to
+// the revoked_tokens table.
+var Revocations RevocationStore = dbRevocationStore{}
+
+type dbRevocationStore struct{}
+
+func (dbRevocationStore) Revoke(jti string, userID uint, expiresAt time.Time) error {
+	// Expired rows are useless, so piggyback the cleanup on writes.
+	database.DB.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{})
+
+	return database.DB.Create(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+func (dbRevocationStore) IsRevoked(jti string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeToken revokes a single access token until it expires.
+func RevokeToken(claims *utils.Claims) error {
+	expiresAt := time.Now().Add(utils.AccessTokenTTL())
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return Revocations.Revoke(claims.ID, claims.UserID, expiresAt)
+}
+
+// RevokeAllForUser invalidates every access and refresh token issued to the
+// user so far by moving the user's cutoff timestamp to now. Personal access
+// tokens are revoked too, since this runs after a password reset or when the
+// account may be compromised.
+func RevokeAllForUser(userID uint) error {
+	// Token times have millisecond precision (see utils), so the cutoff is
+	// kept at the same precision for issuedBefore.
+	now := time.Now().Truncate(time.Millisecond)
+
+	if err := database.DB.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("tokens_valid_after", now).Error; err != nil {
+		return err
+	}
+
+	if err := database.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	if err := database.DB.Model(&models.APIToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	return database.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+// IsTokenRevoked reports whether the token was revoked individually, belongs
+// to a revoked session, or was issued before the user's "log out everywhere"
+// cutoff.
+func IsTokenRevoked(claims *utils.Claims) (bool, error) {
+	if claims.ID != "" {
+		revoked, err := Revocations.IsRevoked(claims.ID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	if claims.SessionID != 0 {
+		active, err := IsSessionActive(claims.SessionID, claims.UserID)
+		if err != nil || !active {
+			return !active, err
+		}
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "tokens_valid_after").First(&user, claims.UserID).Error; err != nil {
+		return true, nil
+	}
+
+	if user.TokensValidAfter == nil || claims.IssuedAt == nil {
+		return user.TokensValidAfter != nil, nil
+	}
+
+	return issuedBefore(claims, *user.TokensValidAfter), nil
+}
+
+// issuedBefore reports whether the token was issued before cutoff. Tokens
+// issued in the same millisecond as the cutoff count as issued after it, so
+// the tokens handed out right after a revocation stay valid.
+func issuedBefore(claims *utils.Claims, cutoff time.Time) bool {
+	return claims.IssuedAt.Time.Before(cutoff.Truncate(time.Millisecond))
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"expense-tracker/pkg/utils"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Exit(m.Run())
+}
+
+func issue(t *testing.T) *utils.Claims {
+	t.Helper()
+	token, err := utils.GenerateToken(1, "user@example.com", "user", 0)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	claims, err := utils.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	return claims
+}
+
+func TestIssuedBeforeCutoff(t *testing.T) {
+	before := issue(t)
+	time.Sleep(2 * time.Millisecond)
+	cutoff := time.Now().Truncate(time.Millisecond)
+	time.Sleep(time.Millisecond)
+	after := issue(t)
+
+	if !issuedBefore(before, cutoff) {
+		t.Error("token issued before the cutoff should be revoked")
+	}
+	if issuedBefore(after, cutoff) {
+		t.Error("token issued after the cutoff should stay valid")
+	}
+}
+
+func TestIssuedBeforeCutoffSameSecond(t *testing.T) {
+	// Wait for the start of a second so the cutoff and the next token share
+	// it, which is the case second-precision comparisons got wrong.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	cutoff := time.Now().Truncate(time.Millisecond)
+	time.Sleep(time.Millisecond)
+	claims := issue(t)
+
+	if claims.IssuedAt.Unix() != cutoff.Unix() {
+		t.Skip("token was not issued in the cutoff's second")
+	}
+	if issuedBefore(claims, cutoff) {
+		t.Error("token issued in the cutoff's second but after it should stay valid")
+	}
+}
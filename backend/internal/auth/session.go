@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+)
+
+// sessionTouchInterval limits how often LastSeenAt is written, so busy
+// clients do not cause a database write per request.
+const sessionTouchInterval = time.Minute
+
+// CreateSession records a new signed-in device for the user.
+func CreateSession(userID uint, userAgent, ip string) (*models.Session, error) {
+	session := models.Session{
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IPAddress:  ip,
+		LastSeenAt: time.Now(),
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// IsSessionActive reports whether the session exists, belongs to the user and
+// has not been revoked.
+func IsSessionActive(sessionID, userID uint) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// TouchSession updates the session's last-seen time and IP.
+func TouchSession(sessionID uint, ip string) {
+	now := time.Now()
+	database.DB.Model(&models.Session{}).
+		Where("id = ? AND last_seen_at < ?", sessionID, now.Add(-sessionTouchInterval)).
+		Updates(map[string]interface{}{
+			"last_seen_at": now,
+			"ip_address":   ip,
+		})
+}
+
+// RevokeSession signs one device out: the session stops being accepted by
+// AuthMiddleware and its refresh tokens can no longer be used.
+func RevokeSession(sessionID, userID uint) error {
+	now := time.Now()
+
+	result := database.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return database.DB.Model(&models.RefreshToken{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", now).Error
+}
+
+// RevokeOtherSessions signs the user out of every device except keepID.
+func RevokeOtherSessions(userID, keepID uint) error {
+	now := time.Now()
+
+	if err := database.DB.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	return database.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND (session_id IS NULL OR session_id <> ?) AND revoked_at IS NULL", userID, keepID).
+		Update("revoked_at", now).Error
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+)
+
+// ThrottlePolicy controls how repeated failures slow a key down. Every
+// failure below Threshold blocks the key for BaseDelay, doubling with each
+// failure (zero disables these delays). Once Threshold failures happen within
+// Window, the key is locked for BaseLockout, doubling with every further
+// failure up to MaxLockout.
+type ThrottlePolicy struct {
+	Threshold   int
+	Window      time.Duration
+	BaseDelay   time.Duration
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+}
+
+// AccountThrottle applies to a single email address, IPThrottle to a single
+// client IP. The IP threshold is higher and there are no per-failure delays
+// because several users can share one address.
+var (
+	AccountThrottle = ThrottlePolicy{
+		Threshold:   utils.IntFromEnv("LOGIN_MAX_ATTEMPTS_ACCOUNT", 5),
+		Window:      utils.DurationFromEnv("LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+		BaseDelay:   utils.DurationFromEnv("LOGIN_DELAY_BASE", time.Second),
+		BaseLockout: utils.DurationFromEnv("LOGIN_LOCKOUT_BASE", 30*time.Second),
+		MaxLockout:  utils.DurationFromEnv("LOGIN_LOCKOUT_MAX", time.Hour),
+	}
+	IPThrottle = ThrottlePolicy{
+		Threshold:   utils.IntFromEnv("LOGIN_MAX_ATTEMPTS_IP", 20),
+		Window:      utils.DurationFromEnv("LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+		BaseLockout: utils.DurationFromEnv("LOGIN_LOCKOUT_BASE", 30*time.Second),
+		MaxLockout:  utils.DurationFromEnv("LOGIN_LOCKOUT_MAX", time.Hour),
+	}
+)
+
+// AccountThrottleKey returns the throttle key for an email address. Keying by
+// the address rather than the user ID means unknown emails lock out exactly
+// like real ones, so lockouts do not reveal which accounts exist.
+func AccountThrottleKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IPThrottleKey returns the throttle key for a client IP.
+func IPThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LockedFor returns how much longer key is locked out, or zero.
+func LockedFor(key string) time.Duration {
+	var row models.LoginThrottle
+	if err := database.DB.Where("throttle_key = ?", key).First(&row).Error; err != nil {
+		return 0
+	}
+
+	if row.LockedUntil == nil {
+		return 0
+	}
+	if remaining := time.Until(*row.LockedUntil); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// RecordFailure counts a failed attempt for key and blocks it for the
+// policy's backoff. The count is incremented in the database so concurrent
+// failures cannot overwrite each other.
+func RecordFailure(key string, policy ThrottlePolicy) {
+	now := time.Now()
+
+	// Failures older than the window are forgotten, unless the key is still
+	// locked out.
+	var failures int
+	if err := database.DB.Raw(`
+		INSERT INTO login_throttles (throttle_key, failures, last_failure_at, created_at, updated_at)
+		VALUES (@key, 1, @now, @now, @now)
+		ON CONFLICT (throttle_key) DO UPDATE SET
+			failures = CASE WHEN `+expiredFailures+` THEN 1 ELSE login_throttles.failures + 1 END,
+			locked_until = CASE WHEN `+expiredFailures+` THEN NULL ELSE login_throttles.locked_until END,
+			last_failure_at = @now,
+			updated_at = @now
+		RETURNING failures`,
+		map[string]interface{}{"key": key, "now": now, "windowStart": now.Add(-policy.Window)},
+	).Scan(&failures).Error; err != nil {
+		log.Printf("❌ Failed to record login failure for %s: %v", key, err)
+		return
+	}
+
+	wait := policy.Backoff(failures)
+	if wait <= 0 {
+		return
+	}
+
+	// Only the failure that produced the current count sets the lock, so a
+	// slower concurrent failure cannot shorten it.
+	if err := database.DB.Model(&models.LoginThrottle{}).
+		Where("throttle_key = ? AND failures = ?", key, failures).
+		Update("locked_until", now.Add(wait)).Error; err != nil {
+		log.Printf("❌ Failed to lock out %s: %v", key, err)
+	}
+}
+
+// expiredFailures matches a throttle row whose failures all fell out of the
+// window and whose lockout is over.
+const expiredFailures = `(login_throttles.last_failure_at < @windowStart AND
+	(login_throttles.locked_until IS NULL OR login_throttles.locked_until < @now))`
+
+// Backoff returns how long a key is blocked after its nth failure within the
+// window: a doubling delay below the threshold, then a doubling lockout.
+func (p ThrottlePolicy) Backoff(failures int) time.Duration {
+	if failures < p.Threshold {
+		if p.BaseDelay <= 0 || failures < 1 {
+			return 0
+		}
+		return doubled(p.BaseDelay, failures-1, p.BaseLockout)
+	}
+	return doubled(p.BaseLockout, failures-p.Threshold, p.MaxLockout)
+}
+
+// doubled returns base doubled n times, capped at max.
+func doubled(base time.Duration, n int, max time.Duration) time.Duration {
+	d := base
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// RecentFailures returns how many failed attempts key has within the
+// policy window.
+func RecentFailures(key string, policy ThrottlePolicy) int {
+	var row models.LoginThrottle
+	if err := database.DB.Where("throttle_key = ?", key).First(&row).Error; err != nil {
+		return 0
+	}
+
+	if time.Since(row.LastFailureAt) > policy.Window {
+		return 0
+	}
+	return row.Failures
+}
+
+// ResetFailures clears the failure count for key after a successful login.
+func ResetFailures(key string) {
+	database.DB.Where("throttle_key = ?", key).Delete(&models.LoginThrottle{})
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/testdb"
+)
+
+var testPolicy = ThrottlePolicy{
+	Threshold:   5,
+	Window:      15 * time.Minute,
+	BaseLockout: 30 * time.Second,
+	MaxLockout:  time.Hour,
+}
+
+func TestRecordFailureLocksOut(t *testing.T) {
+	testdb.Open(t)
+	key := AccountThrottleKey("locked@example.com")
+
+	for i := 1; i < testPolicy.Threshold; i++ {
+		RecordFailure(key, testPolicy)
+		if wait := LockedFor(key); wait != 0 {
+			t.Fatalf("locked for %v after %d failures", wait, i)
+		}
+	}
+
+	RecordFailure(key, testPolicy)
+	if wait := LockedFor(key); wait <= 25*time.Second || wait > testPolicy.BaseLockout {
+		t.Errorf("locked for %v at the threshold, want about %v", wait, testPolicy.BaseLockout)
+	}
+
+	ResetFailures(key)
+	if wait := LockedFor(key); wait != 0 || RecentFailures(key, testPolicy) != 0 {
+		t.Errorf("still locked for %v after a reset", wait)
+	}
+}
+
+func TestRecordFailureConcurrent(t *testing.T) {
+	testdb.Open(t)
+	key := IPThrottleKey("203.0.113.7")
+
+	const attempts = 40
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			RecordFailure(key, testPolicy)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if got := RecentFailures(key, testPolicy); got != attempts {
+		t.Errorf("recorded %d failures, want %d", got, attempts)
+	}
+	// 40 failures with a threshold of 5 double the lockout past the cap.
+	if wait := LockedFor(key); wait <= 59*time.Minute {
+		t.Errorf("locked for %v, want the maximum lockout", wait)
+	}
+}
+
+func TestRecordFailureForgetsOldFailures(t *testing.T) {
+	testdb.Open(t)
+	key := AccountThrottleKey("old@example.com")
+
+	for i := 0; i < 3; i++ {
+		RecordFailure(key, testPolicy)
+	}
+	database.DB.Model(&models.LoginThrottle{}).Where("throttle_key = ?", key).
+		Update("last_failure_at", time.Now().Add(-time.Hour))
+
+	RecordFailure(key, testPolicy)
+	if got := RecentFailures(key, testPolicy); got != 1 {
+		t.Errorf("recent failures = %d, want 1", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := testPolicy
+	policy.BaseDelay = time.Second
+
+	for failures, want := range map[int]time.Duration{
+		0:  0,
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		5:  30 * time.Second,
+		6:  time.Minute,
+		20: time.Hour,
+	} {
+		if got := policy.Backoff(failures); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
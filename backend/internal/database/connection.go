@@ -50,6 +50,8 @@ func Connect() (*gorm.DB, error) {
 	// Connect to database
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
+		// Map driver errors such as unique violations to gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 
 	if err != nil {
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/mailer"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportMe streams a zip archive with everything stored about the current
+// user: profile, workspace memberships, custom categories, transactions,
+// split shares and settlements as JSON, plus the transactions as CSV for
+// spreadsheets.
+func ExportMe(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var categories []models.Category
+	if err := database.DB.Where("user_id = ?", user.ID).Order("id").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	var transactions []models.Transaction
+	if err := database.DB.Where("user_id = ?", user.ID).
+		Scopes(transactionRelations).
+		Order("date, id").
+		Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
+	var memberships []models.WorkspaceMember
+	if err := database.DB.Joins("Workspace").
+		Where("workspace_members.user_id = ?", user.ID).
+		Order("workspace_members.id").
+		Find(&memberships).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch workspaces"})
+		return
+	}
+
+	// Shares of other members' expenses; shares of the user's own expenses
+	// are part of their transactions.
+	splits := []models.TransactionSplit{}
+	if err := database.DB.Where("user_id = ?", user.ID).Order("id").Find(&splits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch splits"})
+		return
+	}
+
+	var settlements []models.Settlement
+	if err := database.DB.Where("from_user_id = ? OR to_user_id = ?", user.ID, user.ID).
+		Scopes(settlementRelations).
+		Order("id").
+		Find(&settlements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settlements"})
+		return
+	}
+
+	categoryResponses := []models.CategoryResponse{}
+	for _, category := range categories {
+		categoryResponses = append(categoryResponses, category.ToResponse())
+	}
+
+	transactionResponses := []models.TransactionResponse{}
+	for _, transaction := range transactions {
+		transactionResponses = append(transactionResponses, transaction.ToResponse())
+	}
+
+	workspaceResponses := []models.WorkspaceResponse{}
+	for _, membership := range memberships {
+		workspaceResponses = append(workspaceResponses, membership.ToWorkspaceResponse())
+	}
+
+	settlementResponses := []models.SettlementResponse{}
+	for _, settlement := range settlements {
+		settlementResponses = append(settlementResponses, settlement.ToResponse())
+	}
+
+	audit.Record(c, models.EventDataExported, user.ID, "")
+
+	filename := fmt.Sprintf("expense-tracker-export-%s.zip", time.Now().Format("2006-01-02"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	defer archive.Close()
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", gin.H{"exported_at": time.Now(), "user": user.ToResponse()}},
+		{"workspaces.json", workspaceResponses},
+		{"categories.json", categoryResponses},
+		{"transactions.json", transactionResponses},
+		{"splits.json", splits},
+		{"settlements.json", settlementResponses},
+	}
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			log.Printf("❌ Failed to write export for user %d: %v", user.ID, err)
+			return
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			log.Printf("❌ Failed to write export for user %d: %v", user.ID, err)
+			return
+		}
+	}
+
+	w, err := archive.Create("transactions.csv")
+	if err != nil {
+		log.Printf("❌ Failed to write export for user %d: %v", user.ID, err)
+		return
+	}
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "date", "type", "amount", "category", "description", "created_at"})
+	for _, t := range transactionResponses {
+		writer.Write([]string{
+			strconv.FormatUint(uint64(t.ID), 10),
+			t.Date.Format("2006-01-02"),
+			t.Type,
+			t.Amount.String(),
+			t.Category.Name,
+			t.Description,
+			t.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	writer.Flush()
+}
+
+// DeleteMe schedules the current account for permanent deletion after the
+// grace period and signs it out everywhere. Logging in again before then
+// cancels the deletion.
+func DeleteMe(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input models.DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !confirmIdentity(c, &user, input.Password, input.Code) {
+		return
+	}
+
+	deleteAt := time.Now().Add(utils.AccountDeletionGrace())
+	if err := database.DB.Model(&user).Update("deletion_scheduled_at", deleteAt).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		return
+	}
+
+	if err := auth.RevokeAllForUser(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	audit.Record(c, models.EventDeletionScheduled, user.ID, "")
+
+	sendEmail(mailer.Message{
+		To:      user.Email,
+		Subject: "Your account is scheduled for deletion",
+		Body: fmt.Sprintf("Hi %s,\n\nYour account and all of its data will be permanently deleted on %s. To keep your account, simply log in again before then.\n",
+			user.Name, deleteAt.Format("January 2, 2006")),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Account scheduled for deletion",
+		"deletion_scheduled_at": deleteAt,
+	})
+}
+
+// cancelScheduledDeletion keeps an account that was scheduled for deletion
+// because its owner logged back in.
+func cancelScheduledDeletion(c *gin.Context, user *models.User) {
+	if user.DeletionScheduledAt == nil {
+		return
+	}
+
+	if err := database.DB.Model(user).Update("deletion_scheduled_at", nil).Error; err != nil {
+		log.Printf("❌ Failed to cancel deletion of user %d: %v", user.ID, err)
+		return
+	}
+	user.DeletionScheduledAt = nil
+	audit.Record(c, models.EventDeletionCancelled, user.ID, "")
+
+	sendEmail(mailer.Message{
+		To:      user.Email,
+		Subject: "Your account deletion was cancelled",
+		Body:    fmt.Sprintf("Hi %s,\n\nYou logged in again, so your account will not be deleted.\n", user.Name),
+	})
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminGetUsers lists accounts, optionally searching name and email.
+func AdminGetUsers(c *gin.Context) {
+	var filter models.AdminUserFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	query := database.DB.Model(&models.User{})
+
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		pattern := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ?", pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Disabled != nil {
+		if *filter.Disabled {
+			query = query.Where("disabled_at IS NOT NULL")
+		} else {
+			query = query.Where("disabled_at IS NULL")
+		}
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var users []models.User
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	response := []models.AdminUserResponse{}
+	for _, user := range users {
+		response = append(response, user.ToAdminResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": response,
+		"pagination": gin.H{
+			"page":       filter.Page,
+			"limit":      filter.Limit,
+			"total":      total,
+			"totalPages": (total + int64(filter.Limit) - 1) / int64(filter.Limit),
+		},
+	})
+}
+
+func AdminGetUser(c *gin.Context) {
+	var user models.User
+	if err := database.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.ToAdminResponse())
+}
+
+// AdminDisableUser blocks an account from logging in and revokes all of its
+// sessions and tokens.
+func AdminDisableUser(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+
+	var user models.User
+	if err := database.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.ID == adminID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot disable your own account"})
+		return
+	}
+
+	if user.DisabledAt == nil {
+		now := time.Now()
+		if err := database.DB.Model(&user).Update("disabled_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable user"})
+			return
+		}
+		user.DisabledAt = &now
+	}
+
+	if err := auth.RevokeAllForUser(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke user tokens"})
+		return
+	}
+
+	audit.RecordAdmin(c, models.EventAdminUserDisabled, user.ID, "")
+	c.JSON(http.StatusOK, user.ToAdminResponse())
+}
+
+func AdminEnableUser(c *gin.Context) {
+	var user models.User
+	if err := database.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := database.DB.Model(&user).Update("disabled_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable user"})
+		return
+	}
+	user.DisabledAt = nil
+
+	audit.RecordAdmin(c, models.EventAdminUserEnabled, user.ID, "")
+	c.JSON(http.StatusOK, user.ToAdminResponse())
+}
+
+// AdminImpersonateUser issues a short-lived token that lets the requesting
+// admin see the API as the user does. Tokens are read-only unless
+// allow_writes is set, and every request made with one is logged.
+func AdminImpersonateUser(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+	adminEmail, _ := c.Get("userEmail")
+
+	var input models.ImpersonateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.ID == adminID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot impersonate yourself"})
+		return
+	}
+
+	if user.Role == models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admins cannot be impersonated"})
+		return
+	}
+
+	if user.DisabledAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account is disabled"})
+		return
+	}
+
+	readOnly := !input.AllowWrites
+	token, err := utils.GenerateImpersonationToken(user.ID, user.Email, user.Role, adminID.(uint), adminEmail.(string), readOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	mode := "read-only"
+	if !readOnly {
+		mode = "read-write"
+	}
+	audit.RecordAdmin(c, models.EventImpersonationStarted, user.ID, mode+": "+input.Reason)
+
+	c.JSON(http.StatusCreated, models.ImpersonationResponse{
+		Token:     token,
+		ExpiresAt: time.Now().Add(utils.ImpersonationTTL()),
+		ReadOnly:  readOnly,
+		User:      user.ToResponse(),
+	})
+}
+
+// AdminGetCategories lists the system categories shared by every user.
+func AdminGetCategories(c *gin.Context) {
+	var categories []models.Category
+
+	if err := database.DB.Where("user_id IS NULL AND workspace_id IS NULL").
+		Order("type, name").
+		Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	response := []models.CategoryResponse{}
+	for _, category := range categories {
+		response = append(response, category.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func AdminCreateCategory(c *gin.Context) {
+	var input models.CategoryInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category := models.Category{
+		Name: input.Name,
+		Type: input.Type,
+		Icon: input.Icon,
+	}
+
+	if category.Icon == "" {
+		category.Icon = "📦"
+	}
+
+	if err := database.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+
+	audit.RecordAdmin(c, models.EventAdminCategoryCreated, 0, fmt.Sprintf("category %d %s", category.ID, category.Name))
+	c.JSON(http.StatusCreated, category.ToResponse())
+}
+
+func AdminUpdateCategory(c *gin.Context) {
+	categoryID := c.Param("id")
+
+	var category models.Category
+
+	if err := database.DB.Where("id = ? AND user_id IS NULL AND workspace_id IS NULL", categoryID).
+		First(&category).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	var input models.CategoryInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category.Name = input.Name
+	category.Type = input.Type
+	if input.Icon != "" {
+		category.Icon = input.Icon
+	}
+
+	if err := database.DB.Save(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		return
+	}
+
+	audit.RecordAdmin(c, models.EventAdminCategoryUpdated, 0, fmt.Sprintf("category %d %s", category.ID, category.Name))
+	c.JSON(http.StatusOK, category.ToResponse())
+}
+
+func AdminDeleteCategory(c *gin.Context) {
+	categoryID := c.Param("id")
+
+	var category models.Category
+
+	if err := database.DB.Where("id = ? AND user_id IS NULL AND workspace_id IS NULL", categoryID).
+		First(&category).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	var transactionCount int64
+	database.DB.Model(&models.Transaction{}).Where("category_id = ?", categoryID).Count(&transactionCount)
+
+	if transactionCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Cannot delete category that is being used in transactions",
+			"message": "Users still have transactions in this category",
+		})
+		return
+	}
+
+	if err := database.DB.Delete(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+
+	audit.RecordAdmin(c, models.EventAdminCategoryDeleted, 0, fmt.Sprintf("category %d %s", category.ID, category.Name))
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/testdb"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminCategoriesExcludeWorkspaceRows(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "member@example.com")
+
+	var membership models.WorkspaceMember
+	if err := database.DB.Where("user_id = ?", user.ID).First(&membership).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	system := models.Category{Name: "Salary", Type: "income"}
+	// A workspace category whose creator was cleared still belongs to the
+	// workspace.
+	workspace := models.Category{Name: "Rent", Type: "expense", WorkspaceID: &membership.WorkspaceID}
+	for _, category := range []*models.Category{&system, &workspace} {
+		if err := database.DB.Create(category).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := gin.New()
+	router.GET("/categories", AdminGetCategories)
+	router.PUT("/categories/:id", AdminUpdateCategory)
+	router.DELETE("/categories/:id", AdminDeleteCategory)
+
+	var listed []models.CategoryResponse
+	doJSON(t, router, http.MethodGet, "/categories", nil, &listed)
+	if len(listed) != 1 || listed[0].ID != system.ID {
+		t.Errorf("listed %+v, want only the system category", listed)
+	}
+
+	path := "/categories/" + strconv.FormatUint(uint64(workspace.ID), 10)
+	update := models.CategoryInput{Name: "Hijacked", Type: "expense"}
+	if w := doJSON(t, router, http.MethodPut, path, update, nil); w.Code != http.StatusNotFound {
+		t.Errorf("update workspace category: got %d", w.Code)
+	}
+	if w := doJSON(t, router, http.MethodDelete, path, nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("delete workspace category: got %d", w.Code)
+	}
+
+	database.DB.First(&workspace, workspace.ID)
+	if workspace.Name != "Rent" {
+		t.Errorf("workspace category renamed to %q", workspace.Name)
+	}
+
+	if w := doJSON(t, router, http.MethodPut, "/categories/"+strconv.FormatUint(uint64(system.ID), 10), update, nil); w.Code != http.StatusOK {
+		t.Errorf("update system category: got %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAPITokens lists the user's active personal access tokens.
+func GetAPITokens(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var tokens []models.APIToken
+	if err := database.DB.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API tokens"})
+		return
+	}
+
+	response := []models.APITokenResponse{}
+	for _, token := range tokens {
+		response = append(response, token.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateAPIToken creates a personal access token. The plain token is only
+// returned in this response.
+func CreateAPIToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input models.APITokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	raw := models.APITokenPrefix + secret
+
+	token := models.APIToken{
+		Name:        input.Name,
+		TokenHash:   utils.HashToken(raw),
+		TokenPrefix: raw[:len(models.APITokenPrefix)+4],
+		Scopes:      strings.Join(uniqueScopes(input.Scopes), " "),
+		UserID:      userID.(uint),
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := database.DB.Create(&token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API token"})
+		return
+	}
+
+	audit.Record(c, models.EventAPITokenCreated, token.UserID, token.Name+" ("+token.Scopes+")")
+	c.JSON(http.StatusCreated, models.CreatedAPITokenResponse{
+		APITokenResponse: token.ToResponse(),
+		Token:            raw,
+	})
+}
+
+// DeleteAPIToken revokes a personal access token.
+func DeleteAPIToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	tokenID := c.Param("id")
+
+	result := database.DB.Model(&models.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API token"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+		return
+	}
+
+	audit.Record(c, models.EventAPITokenRevoked, userID.(uint), "token "+tokenID)
+	c.JSON(http.StatusOK, gin.H{"message": "API token revoked successfully"})
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
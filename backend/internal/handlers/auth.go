@@ -1,13 +1,22 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/auth"
 	"expense-tracker/internal/database"
 	"expense-tracker/internal/models"
 	"expense-tracker/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func Register(c *gin.Context) {
@@ -18,12 +27,21 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	if !checkProofOfWork(c, input.ProofOfWorkInput, registerNeedsProofOfWork(c.ClientIP())) {
+		return
+	}
+
 	var existingUser models.User
 	if err := database.DB.Where("email = ?", input.Email).First(&existingUser).Error; err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
 		return
 	}
 
+	if err := utils.ValidatePassword(input.Password, input.Email, input.Name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	hashedPassword, err := utils.HashPassword(input.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
@@ -36,21 +54,28 @@ func Register(c *gin.Context) {
 		Password: hashedPassword,
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	// The verification token is created with the account so a failure does
+	// not leave behind an account the user cannot register again.
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return sendVerificationEmail(tx, &user)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email)
+	audit.Record(c, models.EventRegister, user.ID, "")
+
+	response, err := startSession(c, &user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.LoginResponse{
-		User:  user.ToResponse(),
-		Token: token,
-	})
+	respondWithTokens(c, http.StatusCreated, response)
 }
 
 func Login(c *gin.Context) {
@@ -61,27 +86,215 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	ipKey := auth.IPThrottleKey(c.ClientIP())
+	accountKey := auth.AccountThrottleKey(input.Email)
+
+	if wait := auth.LockedFor(ipKey); wait > 0 {
+		respondThrottled(c, http.StatusTooManyRequests, wait)
+		return
+	}
+	if wait := auth.LockedFor(accountKey); wait > 0 {
+		respondThrottled(c, http.StatusLocked, wait)
+		return
+	}
+
+	if !checkProofOfWork(c, input.ProofOfWorkInput, loginNeedsProofOfWork(ipKey, accountKey)) {
+		return
+	}
+
 	var user models.User
 	if err := database.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
+		utils.DummyPasswordCheck(input.Password)
+		auth.RecordFailure(ipKey, auth.IPThrottle)
+		auth.RecordFailure(accountKey, auth.AccountThrottle)
+		audit.Record(c, models.EventLoginFailed, 0, "unknown email "+input.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
+	// Accounts created through an identity provider have no password; spend
+	// the same time as a real check so that does not show.
+	if user.Password == "" {
+		utils.DummyPasswordCheck(input.Password)
+	}
+
 	if !utils.CheckPasswordHash(input.Password, user.Password) {
+		auth.RecordFailure(ipKey, auth.IPThrottle)
+		auth.RecordFailure(accountKey, auth.AccountThrottle)
+		audit.Record(c, models.EventLoginFailed, user.ID, "wrong password")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email)
+	auth.ResetFailures(accountKey)
+
+	// Upgrade hashes made with an older algorithm or weaker parameters while
+	// the plain password is at hand.
+	if utils.PasswordNeedsRehash(user.Password) {
+		if hashedPassword, err := utils.HashPassword(input.Password); err == nil {
+			if err := database.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
+				log.Printf("⚠️  Failed to rehash password for user %d: %v", user.ID, err)
+			}
+		}
+	}
+
+	completeLogin(c, &user, "password")
+}
+
+// Refresh exchanges a refresh token for a new access/refresh token pair. Every
+// refresh token is single-use; presenting one that was already rotated revokes
+// the whole family, since it means the token has leaked.
+func Refresh(c *gin.Context) {
+	var input models.RefreshInput
+
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.RefreshToken == "" && auth.CookieAuthEnabled() {
+		if cookie, err := c.Cookie(auth.RefreshTokenCookie); err == nil {
+			if !auth.ValidCSRF(c) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Invalid CSRF token"})
+				return
+			}
+			input.RefreshToken = cookie
+		}
+	}
+
+	if input.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is required"})
+		return
+	}
+
+	var stored models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", utils.HashToken(input.RefreshToken)).First(&stored).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	if stored.UsedAt != nil {
+		revokeTokenFamily(stored.FamilyID)
+		audit.Record(c, models.EventRefreshTokenReuse, stored.UserID, "")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected"})
+		return
+	}
+
+	if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	// Mark the token used only if nobody else got there first, so two
+	// concurrent refreshes with the same token are treated as reuse.
+	result := database.DB.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", stored.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		revokeTokenFamily(stored.FamilyID)
+		audit.Record(c, models.EventRefreshTokenReuse, stored.UserID, "")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected"})
+		return
+	}
+
+	var sessionID uint
+	if stored.SessionID != nil {
+		sessionID = *stored.SessionID
+		if active, err := auth.IsSessionActive(sessionID, stored.UserID); err != nil || !active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, stored.UserID).Error; err != nil || user.DisabledAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	response, err := issueTokens(&user, sessionID, stored.FamilyID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.LoginResponse{
-		User:  user.ToResponse(),
-		Token: token,
-	})
+	respondWithTokens(c, http.StatusOK, response)
+}
+
+// Logout revokes the access token used for the request and, when given, the
+// refresh token family it belongs to. With "all" set, every token issued to
+// the user so far is invalidated instead.
+func Logout(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	tokenClaims := claims.(*utils.Claims)
+
+	// Ending an impersonation only revokes the admin's token; the user's own
+	// sessions are left alone.
+	if tokenClaims.ImpersonatorID != 0 {
+		if err := auth.RevokeToken(tokenClaims); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+
+		audit.RecordActor(c, models.EventImpersonationEnded, tokenClaims.UserID, tokenClaims.ImpersonatorID, "")
+		c.JSON(http.StatusOK, gin.H{"message": "Impersonation ended"})
+		return
+	}
+
+	var input models.LogoutInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if auth.CookieAuthEnabled() {
+		if input.RefreshToken == "" {
+			input.RefreshToken, _ = c.Cookie(auth.RefreshTokenCookie)
+		}
+		auth.ClearAuthCookies(c)
+	}
+
+	if input.All {
+		if err := auth.RevokeAllForUser(tokenClaims.UserID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+
+		audit.Record(c, models.EventLogoutAll, tokenClaims.UserID, "")
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out from all devices"})
+		return
+	}
+
+	if err := auth.RevokeToken(tokenClaims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	if tokenClaims.SessionID != 0 {
+		if err := auth.RevokeSession(tokenClaims.SessionID, tokenClaims.UserID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+	}
+
+	if input.RefreshToken != "" {
+		var stored models.RefreshToken
+		if err := database.DB.Where("token_hash = ? AND user_id = ?", utils.HashToken(input.RefreshToken), tokenClaims.UserID).
+			First(&stored).Error; err == nil {
+			revokeTokenFamily(stored.FamilyID)
+		}
+	}
+
+	audit.Record(c, models.EventLogout, tokenClaims.UserID, "")
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
 func GetMe(c *gin.Context) {
@@ -98,4 +311,136 @@ func GetMe(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, user.ToResponse())
+}
+
+// completeLogin finishes a login once the first factor (password or magic
+// link) has been checked: disabled accounts are refused, users with TOTP get
+// an MFA challenge and everyone else a new session.
+func completeLogin(c *gin.Context, user *models.User, method string) {
+	if user.DisabledAt != nil {
+		audit.Record(c, models.EventLoginFailed, user.ID, "account disabled")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+
+	if user.TOTPEnabledAt != nil {
+		mfaToken, err := utils.GenerateMFAToken(user.ID, user.Email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int(utils.MFATokenTTL.Seconds()),
+		})
+		return
+	}
+
+	response, err := startSession(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	audit.Record(c, models.EventLoginSucceeded, user.ID, method)
+	respondWithTokens(c, http.StatusOK, response)
+}
+
+// startSession records a new session for the device making the request and
+// issues its first token pair. Logging in also cancels a pending account
+// deletion.
+func startSession(c *gin.Context, user *models.User) (models.LoginResponse, error) {
+	cancelScheduledDeletion(c, user)
+
+	session, err := auth.CreateSession(user.ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return issueTokens(user, session.ID, "")
+}
+
+// issueTokens signs a new access token and persists a new refresh token for
+// user, both bound to sessionID. An empty familyID starts a new token family.
+func issueTokens(user *models.User, sessionID uint, familyID string) (models.LoginResponse, error) {
+	token, err := utils.GenerateToken(user.ID, user.Email, user.Role, sessionID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	if familyID == "" {
+		familyID, err = utils.GenerateRandomToken(16)
+		if err != nil {
+			return models.LoginResponse{}, err
+		}
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	stored := models.RefreshToken{
+		TokenHash: utils.HashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+		UserID:    user.ID,
+	}
+	if sessionID != 0 {
+		stored.SessionID = &sessionID
+	}
+	if err := database.DB.Create(&stored).Error; err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return models.LoginResponse{
+		User:         user.ToResponse(),
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(utils.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// respondWithTokens sends a token pair to the client. With AUTH_COOKIES
+// enabled the tokens go into HttpOnly cookies and are left out of the body.
+func respondWithTokens(c *gin.Context, status int, response models.LoginResponse) {
+	if auth.CookieAuthEnabled() {
+		csrfToken, err := auth.SetAuthCookies(c, response.Token, response.RefreshToken)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		response.Token = ""
+		response.RefreshToken = ""
+		response.CSRFToken = csrfToken
+	}
+
+	c.JSON(status, response)
+}
+
+// revokeTokenFamily revokes every refresh token in the family along with the
+// session they belong to.
+func revokeTokenFamily(familyID string) {
+	var stored models.RefreshToken
+	if err := database.DB.Where("family_id = ? AND session_id IS NOT NULL", familyID).First(&stored).Error; err == nil {
+		auth.RevokeSession(*stored.SessionID, stored.UserID)
+	}
+
+	database.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now())
+}
+
+// respondThrottled rejects a login attempt that hit a lockout. status is 429
+// for a throttled client IP and 423 for a locked account.
+func respondThrottled(c *gin.Context, status int, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(status, gin.H{
+		"error":       "Too many failed login attempts, please try again later",
+		"retry_after": seconds,
+	})
 }
\ No newline at end of file
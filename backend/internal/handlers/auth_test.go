@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/testdb"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRefreshRotatesToken(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "refresh@example.com")
+
+	login, err := startSession(testContext(), user)
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/refresh", Refresh)
+
+	var refreshed models.LoginResponse
+	w := doJSON(t, router, http.MethodPost, "/refresh", models.RefreshInput{RefreshToken: login.RefreshToken}, &refreshed)
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: got %d %s", w.Code, w.Body)
+	}
+	if refreshed.RefreshToken == "" || refreshed.RefreshToken == login.RefreshToken {
+		t.Fatal("refresh did not rotate the refresh token")
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/refresh", models.RefreshInput{RefreshToken: refreshed.RefreshToken}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh with rotated token: got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "reuse@example.com")
+
+	login, err := startSession(testContext(), user)
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	other, err := startSession(testContext(), user)
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/refresh", Refresh)
+
+	var refreshed models.LoginResponse
+	if w := doJSON(t, router, http.MethodPost, "/refresh", models.RefreshInput{RefreshToken: login.RefreshToken}, &refreshed); w.Code != http.StatusOK {
+		t.Fatalf("refresh: got %d %s", w.Code, w.Body)
+	}
+
+	// Presenting the already-used token again looks like theft.
+	if w := doJSON(t, router, http.MethodPost, "/refresh", models.RefreshInput{RefreshToken: login.RefreshToken}, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("reused token: got %d, want 401", w.Code)
+	}
+
+	// The legitimate successor is revoked along with the rest of the family.
+	if w := doJSON(t, router, http.MethodPost, "/refresh", models.RefreshInput{RefreshToken: refreshed.RefreshToken}, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("token from the reused family: got %d, want 401", w.Code)
+	}
+
+	var session models.RefreshToken
+	database.DB.Where("user_id = ?", user.ID).Order("id").First(&session)
+	if active, _ := auth.IsSessionActive(*session.SessionID, user.ID); active {
+		t.Error("session of the reused family is still active")
+	}
+
+	var events int64
+	database.DB.Model(&models.SecurityEvent{}).
+		Where("user_id = ? AND type = ?", user.ID, models.EventRefreshTokenReuse).
+		Count(&events)
+	if events == 0 {
+		t.Error("reuse was not recorded in the security log")
+	}
+
+	// Other devices keep working.
+	if w := doJSON(t, router, http.MethodPost, "/refresh", models.RefreshInput{RefreshToken: other.RefreshToken}, nil); w.Code != http.StatusOK {
+		t.Fatalf("token from another session: got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestLoginUpgradesPasswordHash(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "upgrade@example.com")
+
+	// A hash from when bcrypt was the configured algorithm.
+	legacy, err := bcrypt.GenerateFromPassword([]byte("a long enough passphrase"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.DB.Model(user).Update("password", string(legacy))
+
+	router := gin.New()
+	router.POST("/login", Login)
+
+	var login models.LoginResponse
+	w := doJSON(t, router, http.MethodPost, "/login", models.LoginInput{
+		Email:    user.Email,
+		Password: "a long enough passphrase",
+	}, &login)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: got %d %s", w.Code, w.Body)
+	}
+
+	var stored models.User
+	database.DB.First(&stored, user.ID)
+	if !strings.HasPrefix(stored.Password, "$argon2id$") {
+		t.Fatalf("password hash not upgraded: %q", stored.Password)
+	}
+	if !utils.CheckPasswordHash("a long enough passphrase", stored.Password) {
+		t.Error("upgraded hash does not verify")
+	}
+
+	// Logging in again leaves the current hash alone.
+	doJSON(t, router, http.MethodPost, "/login", models.LoginInput{
+		Email:    user.Email,
+		Password: "a long enough passphrase",
+	}, nil)
+	var again models.User
+	database.DB.First(&again, user.ID)
+	if again.Password != stored.Password {
+		t.Error("a current hash was rehashed")
+	}
+}
@@ -10,11 +10,11 @@ import (
 )
 
 func GetCategories(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 
 	var categories []models.Category
 
-	if err := database.DB.Where("user_id IS NULL OR user_id = ?", userID).
+	if err := database.DB.Where("workspace_id IS NULL OR workspace_id = ?", workspaceID).
 		Order("type, name").
 		Find(&categories).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
@@ -30,12 +30,12 @@ func GetCategories(c *gin.Context) {
 }
 
 func GetCategory(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 	categoryID := c.Param("id")
 
 	var category models.Category
 
-	if err := database.DB.Where("id = ? AND (user_id IS NULL OR user_id = ?)", categoryID, userID).
+	if err := database.DB.Where("id = ? AND (workspace_id IS NULL OR workspace_id = ?)", categoryID, workspaceID).
 		First(&category).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
@@ -46,6 +46,7 @@ func GetCategory(c *gin.Context) {
 
 func CreateCategory(c *gin.Context) {
 	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 
 	var input models.CategoryInput
 
@@ -55,12 +56,14 @@ func CreateCategory(c *gin.Context) {
 	}
 
 	userIDUint := userID.(uint)
+	workspaceIDUint := workspaceID.(uint)
 
 	category := models.Category{
-		Name:   input.Name,
-		Type:   input.Type,
-		Icon:   input.Icon,
-		UserID: &userIDUint,
+		Name:        input.Name,
+		Type:        input.Type,
+		Icon:        input.Icon,
+		UserID:      &userIDUint,
+		WorkspaceID: &workspaceIDUint,
 	}
 
 	if category.Icon == "" {
@@ -76,12 +79,12 @@ func CreateCategory(c *gin.Context) {
 }
 
 func UpdateCategory(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 	categoryID := c.Param("id")
 
 	var category models.Category
 
-	if err := database.DB.Where("id = ? AND user_id = ?", categoryID, userID).
+	if err := database.DB.Where("id = ? AND workspace_id = ?", categoryID, workspaceID).
 		First(&category).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found or cannot be updated"})
 		return
@@ -109,12 +112,12 @@ func UpdateCategory(c *gin.Context) {
 }
 
 func DeleteCategory(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 	categoryID := c.Param("id")
 
 	var category models.Category
 
-	if err := database.DB.Where("id = ? AND user_id = ?", categoryID, userID).
+	if err := database.DB.Where("id = ? AND workspace_id = ?", categoryID, workspaceID).
 		First(&category).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found or cannot be deleted"})
 		return
@@ -140,7 +143,7 @@ func DeleteCategory(c *gin.Context) {
 }
 
 func GetCategoriesByType(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 	categoryType := c.Query("type")
 
 	if categoryType != "income" && categoryType != "expense" {
@@ -150,7 +153,7 @@ func GetCategoriesByType(c *gin.Context) {
 
 	var categories []models.Category
 
-	if err := database.DB.Where("(user_id IS NULL OR user_id = ?) AND type = ?", userID, categoryType).
+	if err := database.DB.Where("(workspace_id IS NULL OR workspace_id = ?) AND type = ?", workspaceID, categoryType).
 		Order("name").
 		Find(&categories).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/mailer"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestMagicLink emails a single-use passwordless login link. Like
+// ForgotPassword it always answers the same way, and it sends nothing while
+// the account is locked out.
+func RequestMagicLink(c *gin.Context) {
+	var input models.MagicLinkInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if wait := auth.LockedFor(auth.IPThrottleKey(c.ClientIP())); wait > 0 {
+		respondThrottled(c, http.StatusTooManyRequests, wait)
+		return
+	}
+
+	response := gin.H{"message": "If that email is registered, a login link has been sent"}
+
+	if auth.LockedFor(auth.AccountThrottleKey(input.Email)) > 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("email = ?", input.Email).First(&user).Error; err != nil || user.DisabledAt != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	token, err := utils.GenerateMagicLinkToken(user.ID, user.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate login link"})
+		return
+	}
+
+	audit.Record(c, models.EventMagicLinkRequested, user.ID, "")
+
+	sendEmail(mailer.Message{
+		To:      user.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to log in. It can be used once and expires in %s.\n\n%s\n\nIf you did not ask for this, you can ignore this email.\n",
+			user.Name, utils.MagicLinkTTL(), frontendLink("/magic-link", token)),
+	})
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyMagicLink exchanges a magic link token for a session, or for an MFA
+// challenge when the user has two-factor authentication enabled.
+func VerifyMagicLink(c *gin.Context) {
+	var input models.MagicLinkVerifyInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ipKey := auth.IPThrottleKey(c.ClientIP())
+	if wait := auth.LockedFor(ipKey); wait > 0 {
+		respondThrottled(c, http.StatusTooManyRequests, wait)
+		return
+	}
+
+	claims, err := utils.ValidateMagicLinkToken(input.Token)
+	if err != nil {
+		auth.RecordFailure(ipKey, auth.IPThrottle)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login link"})
+		return
+	}
+
+	if wait := auth.LockedFor(auth.AccountThrottleKey(claims.Email)); wait > 0 {
+		respondThrottled(c, http.StatusLocked, wait)
+		return
+	}
+
+	if revoked, err := auth.IsTokenRevoked(claims); err != nil || revoked {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login link"})
+		return
+	}
+
+	// The link only vouches for the address it was sent to.
+	var user models.User
+	if err := database.DB.First(&user, claims.UserID).Error; err != nil || user.Email != claims.Email {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login link"})
+		return
+	}
+
+	// Single use: the jti is unique, so a concurrent second use fails here.
+	if err := auth.RevokeToken(claims); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login link"})
+		return
+	}
+
+	// Opening the link proves the user owns the address.
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		if err := database.DB.Model(&user).Update("email_verified_at", now).Error; err == nil {
+			user.EmailVerifiedAt = &now
+		}
+	}
+
+	completeLogin(c, &user, "magic_link")
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"expense-tracker/internal/mailer"
+)
+
+// sendEmail delivers msg in the background so the response time does not
+// reveal whether an email was actually sent.
+func sendEmail(msg mailer.Message) {
+	go func() {
+		if err := mailer.Default.Send(msg); err != nil {
+			log.Printf("❌ Failed to send email to %s: %v", msg.To, err)
+		}
+	}()
+}
+
+// frontendLink builds a link to a frontend page carrying a token.
+func frontendLink(path, token string) string {
+	return frontendURL(path, url.Values{"token": {token}})
+}
+
+// frontendURL builds a URL to a frontend page with query parameters.
+func frontendURL(path string, query url.Values) string {
+	base := os.Getenv("FRONTEND_URL")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return strings.TrimRight(base, "/") + path + "?" + query.Encode()
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/testdb"
+	"expense-tracker/pkg/utils"
+)
+
+func TestConsumeTOTPCodeRejectsReplay(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "totp@example.com")
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	user.TOTPSecret = secret
+	user.TOTPEnabledAt = &now
+	database.DB.Save(user)
+
+	code, err := utils.TOTPCode(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !consumeTOTPCode(user, code) {
+		t.Fatal("valid code was rejected")
+	}
+	if consumeTOTPCode(user, code) {
+		t.Error("the same code was accepted twice")
+	}
+}
+
+func TestRecoveryCodesAreSingleUse(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "recovery@example.com")
+
+	codes, err := replaceRecoveryCodes(database.DB, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	// Users often type the code without its dash.
+	if !consumeRecoveryCode(user.ID, strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))) {
+		t.Fatal("valid recovery code was rejected")
+	}
+	if consumeRecoveryCode(user.ID, codes[0]) {
+		t.Error("a recovery code was accepted twice")
+	}
+	if !consumeRecoveryCode(user.ID, codes[1]) {
+		t.Error("another recovery code stopped working")
+	}
+
+	other := testdb.CreateUser(t, "other@example.com")
+	if consumeRecoveryCode(other.ID, codes[2]) {
+		t.Error("a recovery code worked for another user")
+	}
+
+	if _, err := replaceRecoveryCodes(database.DB, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	var remaining int64
+	database.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&remaining)
+	if remaining != recoveryCodeCount || consumeRecoveryCode(user.ID, codes[2]) {
+		t.Error("regenerating did not replace the old codes")
+	}
+}
@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/oidc"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// oidcStateTTL is how long a user has to finish signing in at the provider.
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	errIdentityInUse    = errors.New("identity_in_use")
+	errEmailNotVerified = errors.New("email_not_verified")
+)
+
+// GetOIDCProviders lists the configured identity providers so the frontend
+// can show a button for each.
+func GetOIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": oidc.Names()})
+}
+
+// StartOIDCLogin redirects the browser to the provider's login page.
+func StartOIDCLogin(c *gin.Context) {
+	authURL, err := startOIDCFlow(c, nil)
+	if err != nil {
+		respondOIDCStartError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// StartOIDCLink begins linking another provider account to the current user.
+// It returns the authorization URL for the frontend to navigate to.
+func StartOIDCLink(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := userID.(uint)
+
+	authURL, err := startOIDCFlow(c, &id)
+	if err != nil {
+		respondOIDCStartError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// OIDCCallback handles the provider's redirect back after the user signed
+// in. It links or finds the account and sends the browser to the frontend
+// with a one-time code to exchange at OIDCExchange.
+func OIDCCallback(c *gin.Context) {
+	provider, err := oidc.Get(c.Param("provider"))
+	if err != nil {
+		redirectOIDCResult(c, url.Values{"error": {"unknown_provider"}})
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		redirectOIDCResult(c, url.Values{"error": {providerErr}})
+		return
+	}
+
+	state, ok := consumeOIDCState(c.Query("state"), provider.Name)
+	if !ok {
+		redirectOIDCResult(c, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), state.CodeVerifier, state.Nonce)
+	if err != nil {
+		log.Printf("⚠️  OIDC login with %s failed: %v", provider.Name, err)
+		redirectOIDCResult(c, url.Values{"error": {"exchange_failed"}})
+		return
+	}
+
+	if state.LinkUserID != nil {
+		if _, err := linkIdentity(c, *state.LinkUserID, provider.Name, identity); err != nil {
+			redirectOIDCResult(c, url.Values{"error": {oidcErrorCode(err)}})
+			return
+		}
+
+		redirectOIDCResult(c, url.Values{"linked": {provider.Name}})
+		return
+	}
+
+	user, err := findOrCreateOIDCUser(c, provider.Name, identity)
+	if err != nil {
+		redirectOIDCResult(c, url.Values{"error": {oidcErrorCode(err)}})
+		return
+	}
+
+	code, err := utils.GenerateOIDCLoginToken(user.ID, user.Email)
+	if err != nil {
+		redirectOIDCResult(c, url.Values{"error": {"server_error"}})
+		return
+	}
+
+	redirectOIDCResult(c, url.Values{"code": {code}})
+}
+
+// OIDCExchange trades the one-time code from OIDCCallback for the same
+// response Login returns.
+func OIDCExchange(c *gin.Context) {
+	var input models.OIDCExchangeInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := utils.ValidateOIDCLoginToken(input.Code)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login code"})
+		return
+	}
+
+	if wait := auth.LockedFor(auth.AccountThrottleKey(claims.Email)); wait > 0 {
+		respondThrottled(c, http.StatusLocked, wait)
+		return
+	}
+
+	if revoked, err := auth.IsTokenRevoked(claims); err != nil || revoked {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login code"})
+		return
+	}
+
+	// Single use: the jti is unique, so a concurrent second use fails here.
+	if err := auth.RevokeToken(claims); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login code"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, claims.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login code"})
+		return
+	}
+
+	completeLogin(c, &user, "oidc")
+}
+
+// GetIdentities lists the provider accounts linked to the current user.
+func GetIdentities(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var identities []models.Identity
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch identities"})
+		return
+	}
+
+	response := []models.IdentityResponse{}
+	for _, identity := range identities {
+		response = append(response, identity.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteIdentity unlinks a provider account unless it is the account's last
+// way to sign in.
+func DeleteIdentity(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var identity models.Identity
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&identity).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Identity not found"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if countSignInMethods(&user) <= 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set a password before unlinking your last sign-in method"})
+		return
+	}
+
+	if err := database.DB.Delete(&identity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink identity"})
+		return
+	}
+
+	audit.Record(c, models.EventIdentityUnlinked, user.ID, identity.Provider)
+	c.JSON(http.StatusOK, gin.H{"message": "Identity unlinked successfully"})
+}
+
+// startOIDCFlow stores a new authorization request and returns the URL of the
+// provider named in the route.
+func startOIDCFlow(c *gin.Context, linkUserID *uint) (string, error) {
+	provider, err := oidc.Get(c.Param("provider"))
+	if err != nil {
+		return "", err
+	}
+
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	// Abandoned requests are useless, so piggyback the cleanup on writes.
+	database.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCState{})
+
+	if err := database.DB.Create(&models.OIDCState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider.Name,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}).Error; err != nil {
+		return "", err
+	}
+
+	return authURL, nil
+}
+
+// consumeOIDCState looks up and deletes the pending request for state, so
+// each state value works only once.
+func consumeOIDCState(state, provider string) (*models.OIDCState, bool) {
+	if state == "" {
+		return nil, false
+	}
+
+	var stored models.OIDCState
+	if err := database.DB.Where("state_hash = ? AND provider = ?", utils.HashToken(state), provider).
+		First(&stored).Error; err != nil {
+		return nil, false
+	}
+
+	result := database.DB.Delete(&stored)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, false
+	}
+
+	return &stored, true
+}
+
+// findOrCreateOIDCUser returns the user behind an identity: the one it is
+// already linked to, else an existing account with the same email when both
+// sides verified it, else a new account.
+func findOrCreateOIDCUser(c *gin.Context, provider string, identity *oidc.Identity) (*models.User, error) {
+	var linked models.Identity
+	err := database.DB.Where("provider = ? AND subject = ?", provider, identity.Subject).First(&linked).Error
+	if err == nil {
+		touchIdentity(&linked, identity.Email)
+
+		var user models.User
+		if err := database.DB.First(&user, linked.UserID).Error; err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Only a verified email may be matched to an account or claimed by a
+	// new one; otherwise anyone could take over an address at the provider.
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errEmailNotVerified
+	}
+
+	var user models.User
+	err = database.DB.Where("LOWER(email) = LOWER(?)", identity.Email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		now := time.Now()
+		user = models.User{
+			Name:            oidcDisplayName(identity),
+			Email:           identity.Email,
+			EmailVerifiedAt: &now,
+		}
+		if err := database.DB.Create(&user).Error; err != nil {
+			return nil, err
+		}
+		audit.Record(c, models.EventRegister, user.ID, "oidc:"+provider)
+	} else if err != nil {
+		return nil, err
+	} else if user.EmailVerifiedAt == nil {
+		// Whoever registered this address never proved they own it, and
+		// merging would leave their password working on the real owner's
+		// account.
+		return nil, errEmailNotVerified
+	}
+
+	if _, err := linkIdentity(c, user.ID, provider, identity); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// linkIdentity attaches identity to userID unless it already belongs to
+// another account.
+func linkIdentity(c *gin.Context, userID uint, provider string, identity *oidc.Identity) (*models.Identity, error) {
+	var existing models.Identity
+	err := database.DB.Where("provider = ? AND subject = ?", provider, identity.Subject).First(&existing).Error
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, errIdentityInUse
+		}
+		touchIdentity(&existing, identity.Email)
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	linked := models.Identity{
+		Provider:   provider,
+		Subject:    identity.Subject,
+		Email:      identity.Email,
+		LastUsedAt: &now,
+		UserID:     userID,
+	}
+	if err := database.DB.Create(&linked).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errIdentityInUse
+		}
+		return nil, err
+	}
+
+	audit.Record(c, models.EventIdentityLinked, userID, provider)
+	return &linked, nil
+}
+
+func touchIdentity(identity *models.Identity, email string) {
+	database.DB.Model(identity).Updates(map[string]interface{}{
+		"email":        email,
+		"last_used_at": time.Now(),
+	})
+}
+
+func oidcDisplayName(identity *oidc.Identity) string {
+	if name := strings.TrimSpace(identity.Name); len(name) >= 2 {
+		return name
+	}
+	local, _, _ := strings.Cut(identity.Email, "@")
+	return local
+}
+
+func oidcErrorCode(err error) string {
+	switch {
+	case errors.Is(err, errIdentityInUse):
+		return errIdentityInUse.Error()
+	case errors.Is(err, errEmailNotVerified):
+		return errEmailNotVerified.Error()
+	default:
+		log.Printf("❌ OIDC account lookup failed: %v", err)
+		return "server_error"
+	}
+}
+
+// redirectOIDCResult sends the browser back to the frontend's OIDC page.
+func redirectOIDCResult(c *gin.Context, query url.Values) {
+	c.Redirect(http.StatusFound, frontendURL("/oidc/callback", query))
+}
+
+func respondOIDCStartError(c *gin.Context, err error) {
+	if errors.Is(err, oidc.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	log.Printf("❌ Failed to start OIDC login: %v", err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is unavailable"})
+}
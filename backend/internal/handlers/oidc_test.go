@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/oidc"
+	"expense-tracker/internal/oidc/mockoidc"
+	"expense-tracker/internal/testdb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startMockOIDC runs the mock provider and configures it as "mock". It
+// returns the router serving the OIDC routes and the provider's URL.
+func startMockOIDC(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+
+	var handler http.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := mockoidc.New(server.URL, "expense-tracker", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler = provider.Handler()
+
+	// Runs after the environment below is restored.
+	t.Cleanup(oidc.LoadProviders)
+	t.Setenv("OIDC_PROVIDERS", "mock")
+	t.Setenv("OIDC_MOCK_ISSUER", server.URL)
+	t.Setenv("OIDC_MOCK_CLIENT_ID", "expense-tracker")
+	t.Setenv("OIDC_MOCK_CLIENT_SECRET", "secret")
+	t.Setenv("OIDC_REDIRECT_BASE_URL", "http://api.test/api/auth/oidc")
+	t.Setenv("FRONTEND_URL", "http://app.test")
+	oidc.LoadProviders()
+
+	router := gin.New()
+	router.GET("/api/auth/oidc/:provider", StartOIDCLogin)
+	router.GET("/api/auth/oidc/:provider/callback", OIDCCallback)
+	router.POST("/api/auth/oidc/exchange", OIDCExchange)
+	return router, server.URL
+}
+
+// noRedirects is a client that returns redirects instead of following them.
+var noRedirects = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// redirectTarget returns the Location of a redirect response.
+func redirectTarget(t *testing.T, code int, location string) *url.URL {
+	t.Helper()
+
+	if code != http.StatusFound {
+		t.Fatalf("expected a redirect, got %d", code)
+	}
+	target, err := url.Parse(location)
+	if err != nil {
+		t.Fatalf("bad Location %q: %v", location, err)
+	}
+	return target
+}
+
+// authorizeAt signs email in at the provider and returns the callback URL
+// it redirects to.
+func authorizeAt(t *testing.T, authURL *url.URL, email string, verified bool) *url.URL {
+	t.Helper()
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	if verified {
+		query := authURL.Query()
+		query.Set("login_hint", email)
+		authURL.RawQuery = query.Encode()
+		resp, err = noRedirects.Get(authURL.String())
+	} else {
+		resp, err = noRedirects.PostForm(authURL.String(), url.Values{"email": {email}})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return redirectTarget(t, resp.StatusCode, resp.Header.Get("Location"))
+}
+
+// startLogin begins a login at the router and returns the provider's
+// authorization URL.
+func startLogin(t *testing.T, router http.Handler, issuer string) *url.URL {
+	t.Helper()
+
+	w := doJSON(t, router, http.MethodGet, "/api/auth/oidc/mock", nil, nil)
+	authURL := redirectTarget(t, w.Code, w.Header().Get("Location"))
+	if !strings.HasPrefix(authURL.String(), issuer+"/authorize?") {
+		t.Fatalf("redirected to %s, want the provider", authURL)
+	}
+	return authURL
+}
+
+// callback delivers the provider's redirect to the router and returns the
+// query of the frontend URL it redirects to.
+func callback(t *testing.T, router http.Handler, callbackURL *url.URL) url.Values {
+	t.Helper()
+
+	if callbackURL.Host != "api.test" {
+		t.Fatalf("provider redirected to %s", callbackURL)
+	}
+	w := doJSON(t, router, http.MethodGet, callbackURL.RequestURI(), nil, nil)
+	result := redirectTarget(t, w.Code, w.Header().Get("Location"))
+	if result.Host != "app.test" || result.Path != "/oidc/callback" {
+		t.Fatalf("callback redirected to %s", result)
+	}
+	return result.Query()
+}
+
+func TestOIDCLoginWithPKCE(t *testing.T) {
+	testdb.Open(t)
+	router, issuer := startMockOIDC(t)
+
+	authURL := startLogin(t, router, issuer)
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL without PKCE: %s", authURL)
+	}
+	if query.Get("nonce") == "" || query.Get("state") == "" {
+		t.Fatalf("authorization URL without nonce or state: %s", authURL)
+	}
+
+	// The stored verifier is the one the challenge was derived from.
+	var state models.OIDCState
+	if err := database.DB.First(&state).Error; err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(state.CodeVerifier))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != query.Get("code_challenge") {
+		t.Error("code_challenge does not match the stored verifier")
+	}
+
+	callbackURL := authorizeAt(t, authURL, "oidc@example.com", true)
+	result := callback(t, router, callbackURL)
+	if result.Get("error") != "" || result.Get("code") == "" {
+		t.Fatalf("callback result: %v", result)
+	}
+
+	var login models.LoginResponse
+	w := doJSON(t, router, http.MethodPost, "/api/auth/oidc/exchange", models.OIDCExchangeInput{Code: result.Get("code")}, &login)
+	if w.Code != http.StatusOK {
+		t.Fatalf("exchange: got %d %s", w.Code, w.Body)
+	}
+	if login.Token == "" || login.User.Email != "oidc@example.com" {
+		t.Errorf("exchange response: %+v", login)
+	}
+
+	var identity models.Identity
+	if err := database.DB.Where("provider = ?", "mock").First(&identity).Error; err != nil {
+		t.Fatalf("identity not linked: %v", err)
+	}
+	if identity.UserID != login.User.ID {
+		t.Errorf("identity linked to user %d, want %d", identity.UserID, login.User.ID)
+	}
+
+	// The login code, the state and the authorization code are single use.
+	if w := doJSON(t, router, http.MethodPost, "/api/auth/oidc/exchange", models.OIDCExchangeInput{Code: result.Get("code")}, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("second exchange: got %d", w.Code)
+	}
+	if replay := callback(t, router, callbackURL); replay.Get("error") != "invalid_state" {
+		t.Errorf("replayed callback: %v", replay)
+	}
+}
+
+func TestOIDCProviderRequiresVerifier(t *testing.T) {
+	testdb.Open(t)
+	router, issuer := startMockOIDC(t)
+
+	authURL := startLogin(t, router, issuer)
+	callbackURL := authorizeAt(t, authURL, "pkce@example.com", true)
+
+	// Someone who intercepted the code cannot redeem it without the verifier.
+	resp, err := http.PostForm(issuer+"/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {callbackURL.Query().Get("code")},
+		"redirect_uri":  {"http://api.test/api/auth/oidc/mock/callback"},
+		"client_id":     {"expense-tracker"},
+		"client_secret": {"secret"},
+		"code_verifier": {"not-the-verifier"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusBadRequest || body.Error != "invalid_grant" {
+		t.Errorf("token with wrong verifier: got %d %q", resp.StatusCode, body.Error)
+	}
+
+	// The provider burned the code, so the real callback fails too.
+	if result := callback(t, router, callbackURL); result.Get("error") != "exchange_failed" {
+		t.Errorf("callback after stolen code: %v", result)
+	}
+}
+
+func TestOIDCRejectsUnverifiedEmail(t *testing.T) {
+	testdb.Open(t)
+	router, issuer := startMockOIDC(t)
+
+	authURL := startLogin(t, router, issuer)
+	result := callback(t, router, authorizeAt(t, authURL, "unverified@example.com", false))
+	if result.Get("error") != "email_not_verified" {
+		t.Errorf("callback result: %v", result)
+	}
+
+	var count int64
+	database.DB.Model(&models.User{}).Where("email = ?", "unverified@example.com").Count(&count)
+	if count != 0 {
+		t.Error("an account was created for an unverified email")
+	}
+}
+
+func TestOIDCDoesNotMergeIntoUnverifiedAccount(t *testing.T) {
+	testdb.Open(t)
+	router, issuer := startMockOIDC(t)
+
+	// Someone registered the victim's address with their own password and
+	// never verified it.
+	squatter := testdb.CreateUser(t, "victim@example.com")
+
+	authURL := startLogin(t, router, issuer)
+	result := callback(t, router, authorizeAt(t, authURL, "victim@example.com", true))
+	if result.Get("error") != "email_not_verified" || result.Get("code") != "" {
+		t.Fatalf("callback result: %v", result)
+	}
+
+	var identities int64
+	database.DB.Model(&models.Identity{}).Where("user_id = ?", squatter.ID).Count(&identities)
+	if identities != 0 {
+		t.Error("the provider identity was linked to the unverified account")
+	}
+}
+
+func TestOIDCLinksVerifiedAccount(t *testing.T) {
+	testdb.Open(t)
+	router, issuer := startMockOIDC(t)
+
+	user := testdb.CreateUser(t, "owner@example.com")
+	database.DB.Model(user).Update("email_verified_at", time.Now())
+
+	authURL := startLogin(t, router, issuer)
+	result := callback(t, router, authorizeAt(t, authURL, "owner@example.com", true))
+	if result.Get("code") == "" {
+		t.Fatalf("callback result: %v", result)
+	}
+
+	var identity models.Identity
+	if err := database.DB.Where("provider = ?", "mock").First(&identity).Error; err != nil {
+		t.Fatalf("identity not linked: %v", err)
+	}
+	if identity.UserID != user.ID {
+		t.Errorf("identity linked to user %d, want %d", identity.UserID, user.ID)
+	}
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/passkey"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"gorm.io/gorm"
+)
+
+// BeginPasskeyRegistration starts adding a passkey to the current account.
+func BeginPasskeyRegistration(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	user, err := loadPasskeyUser(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if len(user.WebAuthnHandle) == 0 {
+		handle := make([]byte, 64)
+		if _, err := rand.Read(handle); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey registration"})
+			return
+		}
+		if err := database.DB.Model(user.User).Update("web_authn_handle", handle).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey registration"})
+			return
+		}
+		user.WebAuthnHandle = handle
+	}
+
+	options, session, err := passkey.Default.BeginRegistration(user,
+		webauthn.WithExclusions(webauthn.Credentials(user.WebAuthnCredentials()).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey registration"})
+		return
+	}
+
+	sessionID, err := saveWebAuthnSession(models.WebAuthnRegister, &user.ID, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WebAuthnBeginResponse{SessionID: sessionID, Options: options})
+}
+
+// FinishPasskeyRegistration verifies the authenticator's attestation and
+// stores the new passkey.
+func FinishPasskeyRegistration(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input models.WebAuthnFinishInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, stored, ok := consumeWebAuthnSession(input.SessionID, models.WebAuthnRegister)
+	if !ok || stored.UserID == nil || *stored.UserID != userID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired passkey registration"})
+		return
+	}
+
+	user, err := loadPasskeyUser(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(input.Credential))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey response"})
+		return
+	}
+
+	credential, err := passkey.Default.CreateCredential(user, *session, parsed)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Passkey verification failed"})
+		return
+	}
+
+	name := input.Name
+	if name == "" {
+		name = "Passkey"
+	}
+
+	record := passkey.FromCredential(credential, user.ID, name)
+	if err := database.DB.Create(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "This passkey is already registered"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save passkey"})
+		return
+	}
+
+	audit.Record(c, models.EventPasskeyAdded, user.ID, record.Name)
+	c.JSON(http.StatusCreated, record.ToResponse())
+}
+
+// BeginPasskeyLogin starts a usernameless login: the browser offers any
+// passkey it holds for this site.
+func BeginPasskeyLogin(c *gin.Context) {
+	options, session, err := passkey.Default.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey login"})
+		return
+	}
+
+	sessionID, err := saveWebAuthnSession(models.WebAuthnLogin, nil, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WebAuthnBeginResponse{SessionID: sessionID, Options: options})
+}
+
+// FinishPasskeyLogin verifies the assertion and signs the user in. Passkeys
+// require user verification, so no second factor is asked for.
+func FinishPasskeyLogin(c *gin.Context) {
+	var input models.WebAuthnFinishInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ipKey := auth.IPThrottleKey(c.ClientIP())
+	if wait := auth.LockedFor(ipKey); wait > 0 {
+		respondThrottled(c, http.StatusTooManyRequests, wait)
+		return
+	}
+
+	session, _, ok := consumeWebAuthnSession(input.SessionID, models.WebAuthnLogin)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired passkey login"})
+		return
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(input.Credential))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey response"})
+		return
+	}
+
+	var user *passkey.User
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		var model models.User
+		if err := database.DB.Where("web_authn_handle = ?", userHandle).First(&model).Error; err != nil {
+			return nil, err
+		}
+		loaded, err := loadPasskeyUser(model.ID)
+		if err != nil {
+			return nil, err
+		}
+		user = loaded
+		return loaded, nil
+	}
+
+	_, credential, err := passkey.Default.ValidatePasskeyLogin(findUser, *session, parsed)
+	if err != nil {
+		auth.RecordFailure(ipKey, auth.IPThrottle)
+		if user != nil {
+			audit.Record(c, models.EventLoginFailed, user.ID, "passkey verification failed")
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey verification failed"})
+		return
+	}
+
+	// A signature counter that did not increase means the credential may
+	// have been cloned.
+	if credential.Authenticator.CloneWarning {
+		audit.Record(c, models.EventLoginFailed, user.ID, "passkey sign counter did not increase")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey verification failed"})
+		return
+	}
+
+	if wait := auth.LockedFor(auth.AccountThrottleKey(user.Email)); wait > 0 {
+		respondThrottled(c, http.StatusLocked, wait)
+		return
+	}
+
+	database.DB.Model(&models.WebAuthnCredential{}).
+		Where("credential_id = ? AND user_id = ?", credential.ID, user.ID).
+		Updates(map[string]interface{}{
+			"sign_count":   credential.Authenticator.SignCount,
+			"last_used_at": time.Now(),
+		})
+
+	if user.DisabledAt != nil {
+		audit.Record(c, models.EventLoginFailed, user.ID, "account disabled")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+
+	response, err := startSession(c, user.User)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	audit.Record(c, models.EventLoginSucceeded, user.ID, "passkey")
+	respondWithTokens(c, http.StatusOK, response)
+}
+
+// GetPasskeys lists the current user's passkeys.
+func GetPasskeys(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var credentials []models.WebAuthnCredential
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at").Find(&credentials).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch passkeys"})
+		return
+	}
+
+	response := []models.WebAuthnCredentialResponse{}
+	for _, credential := range credentials {
+		response = append(response, credential.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeletePasskey removes a passkey unless it is the account's last way to
+// sign in.
+func DeletePasskey(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var credential models.WebAuthnCredential
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&credential).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Passkey not found"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if countSignInMethods(&user) <= 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set a password before removing your last sign-in method"})
+		return
+	}
+
+	if err := database.DB.Delete(&credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove passkey"})
+		return
+	}
+
+	audit.Record(c, models.EventPasskeyRemoved, user.ID, credential.Name)
+	c.JSON(http.StatusOK, gin.H{"message": "Passkey removed successfully"})
+}
+
+// countSignInMethods counts the password, linked identities and passkeys an
+// account can sign in with.
+func countSignInMethods(user *models.User) int64 {
+	var identities, passkeys int64
+	database.DB.Model(&models.Identity{}).Where("user_id = ?", user.ID).Count(&identities)
+	database.DB.Model(&models.WebAuthnCredential{}).Where("user_id = ?", user.ID).Count(&passkeys)
+
+	count := identities + passkeys
+	if user.Password != "" {
+		count++
+	}
+	return count
+}
+
+func loadPasskeyUser(userID uint) (*passkey.User, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var credentials []models.WebAuthnCredential
+	if err := database.DB.Where("user_id = ?", user.ID).Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+
+	return &passkey.User{User: &user, Credentials: credentials}, nil
+}
+
+// saveWebAuthnSession stores the server side of a ceremony and returns the
+// opaque ID the client sends back to finish it.
+func saveWebAuthnSession(purpose string, userID *uint, session *webauthn.SessionData) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	// Abandoned ceremonies are useless, so piggyback the cleanup on writes.
+	database.DB.Where("expires_at < ?", time.Now()).Delete(&models.WebAuthnSession{})
+
+	if err := database.DB.Create(&models.WebAuthnSession{
+		TokenHash: utils.HashToken(token),
+		Purpose:   purpose,
+		Data:      string(data),
+		ExpiresAt: time.Now().Add(passkey.CeremonyTTL),
+		UserID:    userID,
+	}).Error; err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeWebAuthnSession looks up and deletes a ceremony so each challenge
+// can be answered only once.
+func consumeWebAuthnSession(token, purpose string) (*webauthn.SessionData, *models.WebAuthnSession, bool) {
+	var stored models.WebAuthnSession
+	if err := database.DB.Where("token_hash = ? AND purpose = ?", utils.HashToken(token), purpose).
+		First(&stored).Error; err != nil {
+		return nil, nil, false
+	}
+
+	result := database.DB.Delete(&stored)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, nil, false
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, nil, false
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(stored.Data), &session); err != nil {
+		return nil, nil, false
+	}
+
+	return &session, &stored, true
+}
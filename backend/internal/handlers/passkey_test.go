@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/passkey"
+	"expense-tracker/internal/testdb"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+const (
+	passkeyRPID   = "localhost"
+	passkeyOrigin = "http://localhost:3000"
+)
+
+var b64 = base64.RawURLEncoding
+
+// softAuthenticator is a platform authenticator in memory: one P-256
+// passkey with "none" attestation that always verifies the user.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := make([]byte, 16)
+	rand.Read(credentialID)
+	return &softAuthenticator{key: key, credentialID: credentialID}
+}
+
+// ceremonyOptions is the part of the begin response an authenticator reads.
+type ceremonyOptions struct {
+	SessionID string `json:"session_id"`
+	Options   struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+			User      struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"publicKey"`
+	} `json:"options"`
+}
+
+func clientData(ceremony, challenge string) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    passkeyOrigin,
+	})
+	return data
+}
+
+// authenticatorData encodes the RP ID hash, the user present and verified
+// flags, the sign count and optionally attested credential data.
+func (a *softAuthenticator) authenticatorData(attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(passkeyRPID))
+	flags := byte(0x01 | 0x04) // UP | UV
+	if attested != nil {
+		flags |= 0x40 // AT
+	}
+
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+// create answers navigator.credentials.create.
+func (a *softAuthenticator) create(t *testing.T, options ceremonyOptions) json.RawMessage {
+	t.Helper()
+
+	handle, err := b64.DecodeString(options.Options.PublicKey.User.ID)
+	if err != nil {
+		t.Fatalf("user handle: %v", err)
+	}
+	a.userHandle = handle
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authenticatorData(attested),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credential(map[string]interface{}{
+		"clientDataJSON":    b64.EncodeToString(clientData("webauthn.create", options.Options.PublicKey.Challenge)),
+		"attestationObject": b64.EncodeToString(attestation),
+		"transports":        []string{"internal"},
+	})
+}
+
+// get answers navigator.credentials.get.
+func (a *softAuthenticator) get(t *testing.T, options ceremonyOptions) json.RawMessage {
+	t.Helper()
+
+	a.signCount++
+	authData := a.authenticatorData(nil)
+	client := clientData("webauthn.get", options.Options.PublicKey.Challenge)
+
+	clientHash := sha256.Sum256(client)
+	digest := sha256.Sum256(append(authData, clientHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credential(map[string]interface{}{
+		"clientDataJSON":    b64.EncodeToString(client),
+		"authenticatorData": b64.EncodeToString(authData),
+		"signature":         b64.EncodeToString(signature),
+		"userHandle":        b64.EncodeToString(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) credential(response map[string]interface{}) json.RawMessage {
+	credential, _ := json.Marshal(map[string]interface{}{
+		"id":                      b64.EncodeToString(a.credentialID),
+		"rawId":                   b64.EncodeToString(a.credentialID),
+		"type":                    "public-key",
+		"authenticatorAttachment": "platform",
+		"response":                response,
+	})
+	return credential
+}
+
+// passkeyRouter serves the passkey routes with userID signed in for the
+// account ones.
+func passkeyRouter(t *testing.T, userID uint) *gin.Engine {
+	t.Helper()
+
+	relyingParty, err := passkey.New(passkeyRPID, "Expense Tracker", []string{passkeyOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := passkey.Default
+	passkey.Default = relyingParty
+	t.Cleanup(func() { passkey.Default = previous })
+
+	router := gin.New()
+	router.POST("/login/begin", BeginPasskeyLogin)
+	router.POST("/login/finish", FinishPasskeyLogin)
+
+	account := router.Group("/me", func(c *gin.Context) { c.Set("userID", userID) })
+	account.POST("/register/begin", BeginPasskeyRegistration)
+	account.POST("/register/finish", FinishPasskeyRegistration)
+	return router
+}
+
+func registerPasskey(t *testing.T, router http.Handler, authenticator *softAuthenticator) {
+	t.Helper()
+
+	var begin ceremonyOptions
+	if w := doJSON(t, router, http.MethodPost, "/me/register/begin", nil, &begin); w.Code != http.StatusOK {
+		t.Fatalf("begin registration: got %d %s", w.Code, w.Body)
+	}
+
+	w := doJSON(t, router, http.MethodPost, "/me/register/finish", models.WebAuthnFinishInput{
+		SessionID:  begin.SessionID,
+		Name:       "Laptop",
+		Credential: authenticator.create(t, begin),
+	}, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("finish registration: got %d %s", w.Code, w.Body)
+	}
+}
+
+func beginPasskeyLogin(t *testing.T, router http.Handler) ceremonyOptions {
+	t.Helper()
+
+	var begin ceremonyOptions
+	if w := doJSON(t, router, http.MethodPost, "/login/begin", nil, &begin); w.Code != http.StatusOK {
+		t.Fatalf("begin login: got %d %s", w.Code, w.Body)
+	}
+	return begin
+}
+
+func TestPasskeyRegisterAndLogin(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "passkey@example.com")
+	router := passkeyRouter(t, user.ID)
+	authenticator := newSoftAuthenticator(t)
+
+	registerPasskey(t, router, authenticator)
+
+	var stored models.WebAuthnCredential
+	if err := database.DB.Where("user_id = ?", user.ID).First(&stored).Error; err != nil {
+		t.Fatalf("passkey not stored: %v", err)
+	}
+	if stored.Name != "Laptop" || string(stored.CredentialID) != string(authenticator.credentialID) {
+		t.Errorf("stored passkey: %+v", stored)
+	}
+
+	begin := beginPasskeyLogin(t, router)
+	assertion := authenticator.get(t, begin)
+
+	var login models.LoginResponse
+	w := doJSON(t, router, http.MethodPost, "/login/finish", models.WebAuthnFinishInput{
+		SessionID:  begin.SessionID,
+		Credential: assertion,
+	}, &login)
+	if w.Code != http.StatusOK {
+		t.Fatalf("finish login: got %d %s", w.Code, w.Body)
+	}
+	if login.Token == "" || login.User.ID != user.ID {
+		t.Errorf("login response: %+v", login)
+	}
+
+	database.DB.First(&stored, stored.ID)
+	if stored.SignCount != authenticator.signCount || stored.LastUsedAt == nil {
+		t.Errorf("sign count %d, last used %v after login", stored.SignCount, stored.LastUsedAt)
+	}
+
+	// The ceremony is single use.
+	w = doJSON(t, router, http.MethodPost, "/login/finish", models.WebAuthnFinishInput{
+		SessionID:  begin.SessionID,
+		Credential: assertion,
+	}, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("replayed assertion: got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestPasskeyLoginRejectsBadAssertions(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, "cloned@example.com")
+	router := passkeyRouter(t, user.ID)
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, router, authenticator)
+
+	finish := func(credential json.RawMessage, session string) int {
+		return doJSON(t, router, http.MethodPost, "/login/finish", models.WebAuthnFinishInput{
+			SessionID:  session,
+			Credential: credential,
+		}, nil).Code
+	}
+
+	// Signed by a different key.
+	impostor := newSoftAuthenticator(t)
+	impostor.credentialID = authenticator.credentialID
+	impostor.userHandle = authenticator.userHandle
+	begin := beginPasskeyLogin(t, router)
+	if code := finish(impostor.get(t, begin), begin.SessionID); code != http.StatusUnauthorized {
+		t.Errorf("wrong key: got %d", code)
+	}
+
+	// Answering another ceremony's challenge.
+	stale := beginPasskeyLogin(t, router)
+	begin = beginPasskeyLogin(t, router)
+	if code := finish(authenticator.get(t, stale), begin.SessionID); code != http.StatusUnauthorized {
+		t.Errorf("wrong challenge: got %d", code)
+	}
+
+	// A successful login, then one whose counter went backwards.
+	begin = beginPasskeyLogin(t, router)
+	if code := finish(authenticator.get(t, begin), begin.SessionID); code != http.StatusOK {
+		t.Fatalf("login: got %d", code)
+	}
+	authenticator.signCount -= 2
+	begin = beginPasskeyLogin(t, router)
+	if code := finish(authenticator.get(t, begin), begin.SessionID); code != http.StatusUnauthorized {
+		t.Errorf("cloned authenticator: got %d", code)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/mailer"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/testdb"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useFileMailer sends the test's email to a file and returns its path.
+func useFileMailer(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "mail.txt")
+	previous := mailer.Default
+	mailer.Default = mailer.NewFileMailer(path)
+	t.Cleanup(func() { mailer.Default = previous })
+	return path
+}
+
+// waitForMail waits for sendEmail, which runs in the background, to write a
+// message containing want.
+func waitForMail(t *testing.T, path, want string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), want) {
+			return string(data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("no email containing %q was sent", want)
+	return ""
+}
+
+var linkToken = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+func TestPasswordResetFlow(t *testing.T) {
+	testdb.Open(t)
+	mailFile := useFileMailer(t)
+	user := testdb.CreateUser(t, "reset@example.com")
+
+	router := gin.New()
+	router.POST("/forgot", ForgotPassword)
+	router.POST("/reset", ResetPassword)
+
+	if w := doJSON(t, router, http.MethodPost, "/forgot", models.ForgotPasswordInput{Email: user.Email}, nil); w.Code != http.StatusOK {
+		t.Fatalf("forgot password: got %d %s", w.Code, w.Body)
+	}
+
+	mail := waitForMail(t, mailFile, "/reset-password")
+	if !strings.Contains(mail, "To: "+user.Email) {
+		t.Fatalf("reset email went to the wrong address:\n%s", mail)
+	}
+	match := linkToken.FindStringSubmatch(mail)
+	if match == nil {
+		t.Fatalf("reset email has no token:\n%s", mail)
+	}
+
+	const newPassword = "plum-harbor-lantern-91"
+	reset := models.ResetPasswordInput{Token: match[1], Password: newPassword}
+	if w := doJSON(t, router, http.MethodPost, "/reset", reset, nil); w.Code != http.StatusOK {
+		t.Fatalf("reset password: got %d %s", w.Code, w.Body)
+	}
+
+	var updated models.User
+	database.DB.First(&updated, user.ID)
+	if !utils.CheckPasswordHash(newPassword, updated.Password) {
+		t.Error("password was not changed")
+	}
+	if updated.TokensValidAfter == nil {
+		t.Error("existing tokens were not revoked")
+	}
+
+	if w := doJSON(t, router, http.MethodPost, "/reset", reset, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("reusing the reset token: got %d, want 400", w.Code)
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	testdb.Open(t)
+	mailFile := useFileMailer(t)
+
+	router := gin.New()
+	router.POST("/forgot", ForgotPassword)
+
+	if w := doJSON(t, router, http.MethodPost, "/forgot", models.ForgotPasswordInput{Email: "nobody@example.com"}, nil); w.Code != http.StatusOK {
+		t.Fatalf("forgot password: got %d, want the same answer as for real accounts", w.Code)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, err := os.Stat(mailFile); !os.IsNotExist(err) {
+		t.Error("an email was sent for an unknown address")
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/pow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProofOfWorkChallenge issues a challenge for the register and login
+// endpoints. The client finds a nonce such that SHA-256(challenge + ":" +
+// nonce) starts with difficulty zero bits and sends both with its request.
+func GetProofOfWorkChallenge(c *gin.Context) {
+	token, challenge, err := pow.Issue()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ProofOfWorkChallengeResponse{
+		Challenge:  token,
+		Difficulty: challenge.Difficulty,
+		Algorithm:  "sha256",
+		ExpiresAt:  time.Unix(challenge.ExpiresAt, 0),
+	})
+}
+
+// loginNeedsProofOfWork reports whether a login from this IP for this email
+// must come with a solved challenge.
+func loginNeedsProofOfWork(ipKey, accountKey string) bool {
+	switch pow.Mode() {
+	case pow.ModeAlways:
+		return true
+	case pow.ModeOff:
+		return false
+	}
+
+	return auth.RecentFailures(ipKey, auth.IPThrottle) >= pow.Auto.AfterFailures ||
+		auth.RecentFailures(accountKey, auth.AccountThrottle) >= pow.Auto.AfterFailures
+}
+
+// registerNeedsProofOfWork reports whether a signup from ip must come with a
+// solved challenge. Recent signups are counted from the security log.
+func registerNeedsProofOfWork(ip string) bool {
+	switch pow.Mode() {
+	case pow.ModeAlways:
+		return true
+	case pow.ModeOff:
+		return false
+	}
+
+	if auth.RecentFailures(auth.IPThrottleKey(ip), auth.IPThrottle) >= pow.Auto.AfterFailures {
+		return true
+	}
+
+	var signups int64
+	database.DB.Model(&models.SecurityEvent{}).
+		Where("type = ? AND ip = ? AND created_at > ?", models.EventRegister, ip, time.Now().Add(-pow.Auto.SignupWindow)).
+		Count(&signups)
+	return signups >= int64(pow.Auto.SignupsPerIP)
+}
+
+// checkProofOfWork verifies and redeems the solution in input when one is
+// required. It responds with 428 and returns false when the client has to
+// solve a (new) challenge first.
+func checkProofOfWork(c *gin.Context, input models.ProofOfWorkInput, required bool) bool {
+	if !required {
+		return true
+	}
+
+	if input.PoWChallenge == "" || input.PoWNonce == "" {
+		respondProofOfWorkRequired(c, "Proof of work required")
+		return false
+	}
+
+	challenge, err := pow.Verify(input.PoWChallenge, input.PoWNonce)
+	if err != nil {
+		respondProofOfWorkRequired(c, "Invalid proof of work: "+err.Error())
+		return false
+	}
+
+	if err := pow.Redeem(challenge); err != nil {
+		if errors.Is(err, pow.ErrRedeemed) {
+			respondProofOfWorkRequired(c, "Invalid proof of work: "+err.Error())
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify proof of work"})
+		return false
+	}
+
+	return true
+}
+
+func respondProofOfWorkRequired(c *gin.Context, message string) {
+	c.JSON(http.StatusPreconditionRequired, gin.H{
+		"error":        message,
+		"pow_required": true,
+	})
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/mailer"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errEmailTaken       = errors.New("email already registered")
+	errEmailChangeStale = errors.New("email change no longer applies")
+)
+
+// UpdateMe updates the current user's profile.
+func UpdateMe(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input models.UpdateProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := database.DB.Model(&user).Update("name", input.Name).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.ToResponse())
+}
+
+// ChangePassword sets a new password after checking the current one (see
+// confirmIdentity for accounts without one), and signs the user out of every
+// other device.
+func ChangePassword(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input models.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !confirmIdentity(c, &user, input.CurrentPassword, input.Code) {
+		return
+	}
+
+	if err := utils.ValidatePassword(input.NewPassword, user.Email, user.Name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(input.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	if err := database.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+
+	var currentSessionID uint
+	if claims, exists := c.Get("claims"); exists {
+		currentSessionID = claims.(*utils.Claims).SessionID
+	}
+	if err := auth.RevokeOtherSessions(user.ID, currentSessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke other sessions"})
+		return
+	}
+
+	audit.Record(c, models.EventPasswordChanged, user.ID, "")
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// RequestEmailChange emails a confirmation link to the new address. The
+// account keeps its current email until the link is used via VerifyEmail.
+func RequestEmailChange(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input models.ChangeEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !confirmIdentity(c, &user, input.Password, input.Code) {
+		return
+	}
+
+	if strings.EqualFold(input.NewEmail, user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New email is the same as the current one"})
+		return
+	}
+
+	var existingUser models.User
+	if err := database.DB.Where("email = ?", input.NewEmail).First(&existingUser).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+		return
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	// Only the latest requested address can be confirmed.
+	now := time.Now()
+	database.DB.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, models.EmailTokenChange).
+		Update("used_at", now)
+
+	ttl := utils.EmailVerificationTTL()
+	change := models.EmailVerificationToken{
+		TokenHash: utils.HashToken(token),
+		Email:     input.NewEmail,
+		Purpose:   models.EmailTokenChange,
+		ExpiresAt: now.Add(ttl),
+		UserID:    user.ID,
+	}
+	if err := database.DB.Create(&change).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create email change request"})
+		return
+	}
+
+	sendEmail(mailer.Message{
+		To:      input.NewEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below to start using this address for your account. It expires in %s.\n\n%s\n",
+			user.Name, ttl, frontendLink("/verify-email", token)),
+	})
+	sendEmail(mailer.Message{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to change the email address of your account to %s. If this was not you, change your password right away.\n",
+			user.Name, input.NewEmail),
+	})
+
+	audit.Record(c, models.EventEmailChangeRequested, user.ID, "new email "+input.NewEmail)
+	c.JSON(http.StatusOK, gin.H{"message": "Confirmation email sent to the new address"})
+}
+
+// applyEmailChange moves the account to the address of a confirmed email
+// change token. The new address counts as verified, and pending password
+// reset links, which went to the old address, stop working.
+func applyEmailChange(token *models.EmailVerificationToken) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).
+			Where("email = ? AND id <> ?", token.Email, token.UserID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errEmailTaken
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.User{}).
+			Where("id = ?", token.UserID).
+			Updates(map[string]interface{}{
+				"email":             token.Email,
+				"email_verified_at": now,
+			})
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+				return errEmailTaken
+			}
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errEmailChangeStale
+		}
+
+		return tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", now).Error
+	})
+}
+
+func respondEmailChangeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+	case errors.Is(err, errEmailChangeStale):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+	}
+}
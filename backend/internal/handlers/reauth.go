@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// confirmIdentity checks that the account holder is present before a
+// sensitive change. Accounts with a password must enter it. Accounts created
+// through an identity provider have none, so they confirm with a current
+// TOTP code, or by having signed in (OIDC, passkey or magic link) within
+// utils.ReauthMaxAge. On failure it writes the response and returns false.
+func confirmIdentity(c *gin.Context, user *models.User, password, code string) bool {
+	if user.Password != "" {
+		if !utils.CheckPasswordHash(password, user.Password) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+			return false
+		}
+		return true
+	}
+
+	if code != "" && user.TOTPEnabledAt != nil {
+		if !consumeTOTPCode(user, code) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+			return false
+		}
+		return true
+	}
+
+	if signedInRecently(c, user.ID) {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":           "This account has no password. Sign in again or enter a two-factor code to confirm this change",
+		"reauth_required": true,
+	})
+	return false
+}
+
+// signedInRecently reports whether the current access token belongs to a
+// session started within utils.ReauthMaxAge.
+func signedInRecently(c *gin.Context, userID uint) bool {
+	claims, exists := c.Get("claims")
+	if !exists || claims.(*utils.Claims).SessionID == 0 {
+		return false
+	}
+
+	var session models.Session
+	if err := database.DB.
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", claims.(*utils.Claims).SessionID, userID).
+		First(&session).Error; err != nil {
+		return false
+	}
+	return time.Since(session.CreatedAt) <= utils.ReauthMaxAge()
+}
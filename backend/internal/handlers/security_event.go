@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetSecurityEvents lists the audit trail of the current user's account,
+// newest first.
+func GetSecurityEvents(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var filter models.SecurityEventFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := database.DB.Model(&models.SecurityEvent{}).Where("user_id = ?", userID)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	listSecurityEvents(c, query, filter)
+}
+
+// AdminGetSecurityEvents lists security events across all accounts,
+// filtered by user, type and IP.
+func AdminGetSecurityEvents(c *gin.Context) {
+	var filter models.SecurityEventFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := database.DB.Model(&models.SecurityEvent{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+
+	listSecurityEvents(c, query, filter)
+}
+
+func listSecurityEvents(c *gin.Context, query *gorm.DB, filter models.SecurityEventFilter) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	if filter.StartDate != nil {
+		query = query.Where("created_at >= ?", filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("created_at <= ?", filter.EndDate)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var events []models.SecurityEvent
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("created_at DESC, id DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security events"})
+		return
+	}
+
+	response := []models.SecurityEventResponse{}
+	for _, event := range events {
+		response = append(response, event.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": response,
+		"pagination": gin.H{
+			"page":       filter.Page,
+			"limit":      filter.Limit,
+			"total":      total,
+			"totalPages": (total + int64(filter.Limit) - 1) / int64(filter.Limit),
+		},
+	})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSessions lists the devices the user is currently signed in on.
+func GetSessions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var currentID uint
+	if claims, exists := c.Get("claims"); exists {
+		currentID = claims.(*utils.Claims).SessionID
+	}
+
+	// Sessions idle for longer than a refresh token lives cannot be resumed.
+	var sessions []models.Session
+	if err := database.DB.Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, time.Now().Add(-utils.RefreshTokenTTL())).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	response := []models.SessionResponse{}
+	for _, session := range sessions {
+		response = append(response, session.ToResponse(currentID))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteSession signs the user out of one device.
+func DeleteSession(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID := c.Param("id")
+
+	var session models.Session
+	if err := database.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := auth.RevokeSession(session.ID, session.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	audit.Record(c, models.EventSessionRevoked, session.UserID, fmt.Sprintf("session %d", session.ID))
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
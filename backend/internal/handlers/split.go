@@ -0,0 +1,541 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/money"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// participantKey identifies a user (UserID) or a contact (ContactID).
+type participantKey struct {
+	UserID    uint
+	ContactID uint
+}
+
+func keyOf(userID, contactID *uint) participantKey {
+	var key participantKey
+	if userID != nil {
+		key.UserID = *userID
+	}
+	if contactID != nil {
+		key.ContactID = *contactID
+	}
+	return key
+}
+
+// GetContacts lists the contacts of the workspace.
+func GetContacts(c *gin.Context) {
+	workspaceID, _ := c.Get("workspaceID")
+
+	var contacts []models.Contact
+	if err := database.DB.Where("workspace_id = ?", workspaceID).Order("name").Find(&contacts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contacts"})
+		return
+	}
+
+	response := []models.ContactResponse{}
+	for _, contact := range contacts {
+		response = append(response, contact.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateContact adds a person without an account to split expenses with.
+func CreateContact(c *gin.Context) {
+	workspaceID, _ := c.Get("workspaceID")
+
+	var input models.ContactInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact := models.Contact{WorkspaceID: workspaceID.(uint), Name: input.Name}
+	if err := database.DB.Create(&contact).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A contact with this name already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create contact"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contact.ToResponse())
+}
+
+// DeleteContact removes a contact that is not part of any split or
+// settlement.
+func DeleteContact(c *gin.Context) {
+	workspaceID, _ := c.Get("workspaceID")
+
+	var contact models.Contact
+	if err := database.DB.Where("id = ? AND workspace_id = ?", c.Param("id"), workspaceID).First(&contact).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+		return
+	}
+
+	var splits, settlements int64
+	database.DB.Model(&models.TransactionSplit{}).Where("contact_id = ?", contact.ID).Count(&splits)
+	database.DB.Model(&models.Settlement{}).
+		Where("from_contact_id = ? OR to_contact_id = ?", contact.ID, contact.ID).
+		Count(&settlements)
+	if splits+settlements > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete a contact that takes part in split expenses"})
+		return
+	}
+
+	if err := database.DB.Delete(&contact).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contact"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contact deleted successfully"})
+}
+
+// GetBalances returns what every participant of the workspace's split
+// expenses is owed or owes, and the payments that would settle everything.
+func GetBalances(c *gin.Context) {
+	workspaceID, _ := c.Get("workspaceID")
+
+	balances, err := computeBalances(workspaceID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balances"})
+		return
+	}
+
+	keys := make([]participantKey, 0, len(balances))
+	for key, amount := range balances {
+		if amount != 0 {
+			keys = append(keys, key)
+		}
+	}
+	sortParticipants(keys, balances)
+
+	participants, err := describeParticipants(keys)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balances"})
+		return
+	}
+
+	response := models.BalancesResponse{
+		Balances: []models.Balance{},
+		SettleUp: []models.SettleUpPayment{},
+	}
+	for _, key := range keys {
+		response.Balances = append(response.Balances, models.Balance{
+			Participant: participants[key],
+			Amount:      balances[key],
+		})
+	}
+	for _, payment := range settleUp(balances) {
+		response.SettleUp = append(response.SettleUp, models.SettleUpPayment{
+			From:   participants[payment.from],
+			To:     participants[payment.to],
+			Amount: payment.amount,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSettlements lists the settlements of the workspace, newest first.
+func GetSettlements(c *gin.Context) {
+	workspaceID, _ := c.Get("workspaceID")
+
+	var settlements []models.Settlement
+	if err := database.DB.Scopes(settlementRelations).
+		Joins("JOIN transactions ON transactions.id = settlements.transaction_id AND transactions.deleted_at IS NULL").
+		Where("settlements.workspace_id = ?", workspaceID).
+		Order("settlements.created_at DESC").
+		Find(&settlements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settlements"})
+		return
+	}
+
+	response := []models.SettlementResponse{}
+	for _, settlement := range settlements {
+		response = append(response, settlement.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateSettlement records a payment between two participants, one of them
+// the current user, and books it as the user's income or expense.
+func CreateSettlement(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
+
+	var input models.SettlementInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, participant := range []models.ParticipantInput{input.From, input.To} {
+		if err := checkParticipant(workspaceID.(uint), participant); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	from := keyOf(input.From.UserID, input.From.ContactID)
+	to := keyOf(input.To.UserID, input.To.ContactID)
+	me := participantKey{UserID: userID.(uint)}
+	if from == to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot settle with yourself"})
+		return
+	}
+	if from != me && to != me {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You must be the payer or the recipient of a settlement"})
+		return
+	}
+
+	var category models.Category
+	if err := database.DB.Where("id = ? AND (workspace_id IS NULL OR workspace_id = ?)", input.CategoryID, workspaceID).
+		First(&category).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
+		return
+	}
+
+	other := to
+	transactionType := "expense"
+	if to == me {
+		other = from
+		transactionType = "income"
+	}
+
+	participants, err := describeParticipants([]participantKey{other})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record settlement"})
+		return
+	}
+
+	settlement := models.Settlement{
+		WorkspaceID:   workspaceID.(uint),
+		FromUserID:    input.From.UserID,
+		FromContactID: input.From.ContactID,
+		ToUserID:      input.To.UserID,
+		ToContactID:   input.To.ContactID,
+		Amount:        input.Amount,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		transaction := models.Transaction{
+			Amount:      input.Amount,
+			Description: "Settlement with " + participants[other].Name,
+			Date:        input.Date,
+			Type:        transactionType,
+			CategoryID:  category.ID,
+			UserID:      userID.(uint),
+			WorkspaceID: workspaceID.(uint),
+		}
+		if err := tx.Create(&transaction).Error; err != nil {
+			return err
+		}
+
+		settlement.TransactionID = transaction.ID
+		return tx.Create(&settlement).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record settlement"})
+		return
+	}
+
+	database.DB.Scopes(settlementRelations).First(&settlement, settlement.ID)
+	c.JSON(http.StatusCreated, settlement.ToResponse())
+}
+
+// splitsFromInput builds the splits of a transaction input, if any. It
+// responds with 400 and returns false when the split is invalid.
+func splitsFromInput(c *gin.Context, workspaceID uint, input *models.TransactionInput) ([]models.TransactionSplit, bool) {
+	if input.Split == nil {
+		return nil, true
+	}
+
+	if input.Type != "expense" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only expenses can be split"})
+		return nil, false
+	}
+
+	splits, err := buildSplits(workspaceID, input.Amount, input.Split)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return splits, true
+}
+
+// buildSplits divides total among the participants of input. Cents lost to
+// rounding go to the first participants.
+func buildSplits(workspaceID uint, total money.Amount, input *models.TransactionSplitInput) ([]models.TransactionSplit, error) {
+	shares := make([]money.Amount, len(input.Participants))
+
+	seen := map[participantKey]bool{}
+	for _, participant := range input.Participants {
+		if err := checkParticipant(workspaceID, participant.ParticipantInput); err != nil {
+			return nil, err
+		}
+		key := keyOf(participant.UserID, participant.ContactID)
+		if seen[key] {
+			return nil, errors.New("each participant can only appear once in a split")
+		}
+		seen[key] = true
+	}
+
+	switch input.Method {
+	case models.SplitEqual:
+		for i := range shares {
+			shares[i] = total / money.Amount(len(shares))
+		}
+		distributeRemainder(shares, total)
+
+	case models.SplitExact:
+		var sum money.Amount
+		for i, participant := range input.Participants {
+			if participant.Amount < 0 {
+				return nil, errors.New("split amounts cannot be negative")
+			}
+			shares[i] = participant.Amount
+			sum += shares[i]
+		}
+		if sum != total {
+			return nil, errors.New("split amounts must add up to the transaction amount")
+		}
+
+	case models.SplitPercentage:
+		var percent float64
+		for i, participant := range input.Participants {
+			if participant.Percentage < 0 || participant.Percentage > 100 {
+				return nil, errors.New("split percentages must be between 0 and 100")
+			}
+			percent += participant.Percentage
+			shares[i] = money.Amount(math.Floor(float64(total) * participant.Percentage / 100))
+		}
+		if math.Abs(percent-100) > 0.0001 {
+			return nil, errors.New("split percentages must add up to 100")
+		}
+		// Rounding can only be corrected upwards, so percentages a hair over
+		// 100 could still produce shares larger than the transaction.
+		distributeRemainder(shares, total)
+		if sumShares(shares) != total {
+			return nil, errors.New("split percentages must add up to 100")
+		}
+	}
+
+	splits := make([]models.TransactionSplit, len(shares))
+	for i, participant := range input.Participants {
+		splits[i] = models.TransactionSplit{
+			WorkspaceID: workspaceID,
+			UserID:      participant.UserID,
+			ContactID:   participant.ContactID,
+			Amount:      shares[i],
+		}
+	}
+	return splits, nil
+}
+
+func distributeRemainder(shares []money.Amount, total money.Amount) {
+	sum := sumShares(shares)
+	for i := 0; sum < total; i = (i + 1) % len(shares) {
+		shares[i]++
+		sum++
+	}
+}
+
+func sumShares(shares []money.Amount) money.Amount {
+	var sum money.Amount
+	for _, share := range shares {
+		sum += share
+	}
+	return sum
+}
+
+// checkParticipant makes sure participant names exactly one member or
+// contact of the workspace.
+func checkParticipant(workspaceID uint, participant models.ParticipantInput) error {
+	switch {
+	case participant.UserID != nil && participant.ContactID == nil:
+		var count int64
+		database.DB.Model(&models.WorkspaceMember{}).
+			Where("workspace_id = ? AND user_id = ?", workspaceID, *participant.UserID).
+			Count(&count)
+		if count == 0 {
+			return errors.New("participant is not a member of this workspace")
+		}
+	case participant.ContactID != nil && participant.UserID == nil:
+		var count int64
+		database.DB.Model(&models.Contact{}).
+			Where("workspace_id = ? AND id = ?", workspaceID, *participant.ContactID).
+			Count(&count)
+		if count == 0 {
+			return errors.New("contact not found")
+		}
+	default:
+		return errors.New("a participant needs either user_id or contact_id")
+	}
+	return nil
+}
+
+// computeBalances nets split shares and settlements of a workspace. The payer
+// of a split expense is owed every other participant's share.
+func computeBalances(workspaceID uint) (map[participantKey]money.Amount, error) {
+	type shareRow struct {
+		PayerID   uint
+		UserID    *uint
+		ContactID *uint
+		Amount    money.Amount
+	}
+
+	var shares []shareRow
+	if err := database.DB.Model(&models.TransactionSplit{}).
+		Select("transactions.payer_user_id AS payer_id, transaction_splits.user_id, transaction_splits.contact_id, transaction_splits.amount").
+		Joins("JOIN transactions ON transactions.id = transaction_splits.transaction_id AND transactions.deleted_at IS NULL").
+		Where("transaction_splits.workspace_id = ?", workspaceID).
+		Scan(&shares).Error; err != nil {
+		return nil, err
+	}
+
+	var settlements []models.Settlement
+	if err := database.DB.
+		Joins("JOIN transactions ON transactions.id = settlements.transaction_id AND transactions.deleted_at IS NULL").
+		Where("settlements.workspace_id = ?", workspaceID).
+		Find(&settlements).Error; err != nil {
+		return nil, err
+	}
+
+	balances := map[participantKey]money.Amount{}
+	for _, share := range shares {
+		payer := participantKey{UserID: share.PayerID}
+		participant := keyOf(share.UserID, share.ContactID)
+		if participant == payer {
+			continue
+		}
+		balances[payer] += share.Amount
+		balances[participant] -= share.Amount
+	}
+	for _, settlement := range settlements {
+		balances[keyOf(settlement.FromUserID, settlement.FromContactID)] += settlement.Amount
+		balances[keyOf(settlement.ToUserID, settlement.ToContactID)] -= settlement.Amount
+	}
+
+	return balances, nil
+}
+
+type payment struct {
+	from, to participantKey
+	amount   money.Amount
+}
+
+// settleUp greedily pairs the largest debtor with the largest creditor. This
+// needs at most one payment fewer than there are participants with a
+// balance.
+func settleUp(balances map[participantKey]money.Amount) []payment {
+	var creditors, debtors []participantKey
+	remaining := map[participantKey]money.Amount{}
+	for key, amount := range balances {
+		switch {
+		case amount > 0:
+			creditors = append(creditors, key)
+			remaining[key] = amount
+		case amount < 0:
+			debtors = append(debtors, key)
+			remaining[key] = -amount
+		}
+	}
+	sortParticipants(creditors, remaining)
+	sortParticipants(debtors, remaining)
+
+	var payments []payment
+	for i, j := 0, 0; i < len(creditors) && j < len(debtors); {
+		creditor, debtor := creditors[i], debtors[j]
+		amount := min(remaining[creditor], remaining[debtor])
+		payments = append(payments, payment{from: debtor, to: creditor, amount: amount})
+
+		remaining[creditor] -= amount
+		remaining[debtor] -= amount
+		if remaining[creditor] == 0 {
+			i++
+		}
+		if remaining[debtor] == 0 {
+			j++
+		}
+	}
+	return payments
+}
+
+// sortParticipants orders keys by amount, largest first, with ties broken by
+// ID so results are stable.
+func sortParticipants(keys []participantKey, amounts map[participantKey]money.Amount) {
+	sort.Slice(keys, func(a, b int) bool {
+		if amounts[keys[a]] != amounts[keys[b]] {
+			return amounts[keys[a]] > amounts[keys[b]]
+		}
+		if keys[a].UserID != keys[b].UserID {
+			return keys[a].UserID < keys[b].UserID
+		}
+		return keys[a].ContactID < keys[b].ContactID
+	})
+}
+
+// describeParticipants loads the names of users and contacts.
+func describeParticipants(keys []participantKey) (map[participantKey]models.ParticipantResponse, error) {
+	var userIDs, contactIDs []uint
+	for _, key := range keys {
+		if key.UserID != 0 {
+			userIDs = append(userIDs, key.UserID)
+		} else {
+			contactIDs = append(contactIDs, key.ContactID)
+		}
+	}
+
+	users := map[uint]*models.User{}
+	if len(userIDs) > 0 {
+		var rows []models.User
+		if err := database.DB.Select("id", "name").Where("id IN ?", userIDs).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			users[rows[i].ID] = &rows[i]
+		}
+	}
+
+	contacts := map[uint]*models.Contact{}
+	if len(contactIDs) > 0 {
+		var rows []models.Contact
+		if err := database.DB.Where("id IN ?", contactIDs).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			contacts[rows[i].ID] = &rows[i]
+		}
+	}
+
+	participants := map[participantKey]models.ParticipantResponse{}
+	for _, key := range keys {
+		if key.UserID != 0 {
+			id := key.UserID
+			participants[key] = models.NewParticipantResponse(&id, users[id], nil, nil)
+		} else {
+			id := key.ContactID
+			participants[key] = models.NewParticipantResponse(nil, nil, &id, contacts[id])
+		}
+	}
+	return participants, nil
+}
+
+func transactionRelations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Category").Preload("Splits.User").Preload("Splits.Contact")
+}
+
+func settlementRelations(db *gorm.DB) *gorm.DB {
+	return db.Preload("FromUser").Preload("FromContact").Preload("ToUser").Preload("ToContact")
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/testdb"
+	"expense-tracker/pkg/money"
+)
+
+// splitFixture is a shared workspace with two members and a contact.
+type splitFixture struct {
+	workspace uint
+	alice     uint
+	bob       uint
+	carol     uint // contact
+}
+
+func newSplitFixture(t *testing.T) splitFixture {
+	t.Helper()
+	testdb.Open(t)
+
+	alice := testdb.CreateUser(t, "alice@example.com")
+	bob := testdb.CreateUser(t, "bob@example.com")
+
+	workspace := models.Workspace{Name: "Trip"}
+	if err := database.DB.Create(&workspace).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []uint{alice.ID, bob.ID} {
+		member := models.WorkspaceMember{WorkspaceID: workspace.ID, UserID: userID, Role: models.WorkspaceEditor}
+		if err := database.DB.Create(&member).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	carol := models.Contact{WorkspaceID: workspace.ID, Name: "Carol"}
+	if err := database.DB.Create(&carol).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return splitFixture{workspace: workspace.ID, alice: alice.ID, bob: bob.ID, carol: carol.ID}
+}
+
+func (f splitFixture) user(id uint) models.SplitParticipantInput {
+	return models.SplitParticipantInput{ParticipantInput: models.ParticipantInput{UserID: &id}}
+}
+
+func (f splitFixture) contact(id uint) models.SplitParticipantInput {
+	return models.SplitParticipantInput{ParticipantInput: models.ParticipantInput{ContactID: &id}}
+}
+
+func splitAmounts(splits []models.TransactionSplit) []money.Amount {
+	amounts := make([]money.Amount, len(splits))
+	for i, split := range splits {
+		amounts[i] = split.Amount
+	}
+	return amounts
+}
+
+func TestBuildSplitsEqual(t *testing.T) {
+	f := newSplitFixture(t)
+
+	splits, err := buildSplits(f.workspace, 1000, &models.TransactionSplitInput{
+		Method:       models.SplitEqual,
+		Participants: []models.SplitParticipantInput{f.user(f.alice), f.user(f.bob), f.contact(f.carol)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cent lost to rounding goes to the first participant.
+	if got, want := splitAmounts(splits), []money.Amount{334, 333, 333}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shares = %v, want %v", got, want)
+	}
+	if splits[2].ContactID == nil || *splits[2].ContactID != f.carol || splits[2].WorkspaceID != f.workspace {
+		t.Errorf("contact split = %+v", splits[2])
+	}
+}
+
+func TestBuildSplitsExact(t *testing.T) {
+	f := newSplitFixture(t)
+
+	alice, bob := f.user(f.alice), f.user(f.bob)
+	alice.Amount, bob.Amount = 700, 300
+	splits, err := buildSplits(f.workspace, 1000, &models.TransactionSplitInput{
+		Method:       models.SplitExact,
+		Participants: []models.SplitParticipantInput{alice, bob},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := splitAmounts(splits), []money.Amount{700, 300}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shares = %v, want %v", got, want)
+	}
+
+	bob.Amount = 299
+	if _, err := buildSplits(f.workspace, 1000, &models.TransactionSplitInput{
+		Method:       models.SplitExact,
+		Participants: []models.SplitParticipantInput{alice, bob},
+	}); err == nil {
+		t.Error("amounts short of the total were accepted")
+	}
+
+	alice.Amount, bob.Amount = 1100, -100
+	if _, err := buildSplits(f.workspace, 1000, &models.TransactionSplitInput{
+		Method:       models.SplitExact,
+		Participants: []models.SplitParticipantInput{alice, bob},
+	}); err == nil {
+		t.Error("a negative amount was accepted")
+	}
+}
+
+func TestBuildSplitsPercentage(t *testing.T) {
+	f := newSplitFixture(t)
+
+	split := func(total money.Amount, percentages ...float64) ([]models.TransactionSplit, error) {
+		participants := []models.SplitParticipantInput{f.user(f.alice), f.user(f.bob), f.contact(f.carol)}[:len(percentages)]
+		for i := range participants {
+			participants[i].Percentage = percentages[i]
+		}
+		return buildSplits(f.workspace, total, &models.TransactionSplitInput{
+			Method:       models.SplitPercentage,
+			Participants: participants,
+		})
+	}
+
+	// Flooring loses a cent, which goes to the first participant.
+	splits, err := split(1000, 33.33, 33.33, 33.34)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := splitAmounts(splits), []money.Amount{334, 333, 333}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shares = %v, want %v", got, want)
+	}
+
+	for name, percentages := range map[string][]float64{
+		"under 100":    {50, 40},
+		"over 100":     {60, 50},
+		"above 100":    {150, -50},
+		"negative":     {-10, 110},
+		"single above": {100.00001},
+	} {
+		if _, err := split(1000, percentages...); err == nil {
+			t.Errorf("%s: %v accepted", name, percentages)
+		}
+	}
+
+	// Within the tolerance on the percentages, but the shares of a large
+	// amount would add up to more than the transaction.
+	if splits, err := split(100000000, 50.00004, 50.00004); err == nil {
+		t.Errorf("overshooting percentages accepted: %v", splitAmounts(splits))
+	}
+}
+
+func TestBuildSplitsRejectsParticipants(t *testing.T) {
+	f := newSplitFixture(t)
+	outsider := testdb.CreateUser(t, "mallory@example.com")
+
+	other := models.Contact{WorkspaceID: f.workspace + 100, Name: "Elsewhere"}
+	database.DB.Create(&other)
+
+	for name, participants := range map[string][]models.SplitParticipantInput{
+		"non-member":       {f.user(f.alice), f.user(outsider.ID)},
+		"foreign contact":  {f.user(f.alice), f.contact(other.ID)},
+		"duplicate":        {f.user(f.alice), f.user(f.alice)},
+		"neither":          {f.user(f.alice), {}},
+		"user and contact": {{ParticipantInput: models.ParticipantInput{UserID: &f.bob, ContactID: &f.carol}}},
+	} {
+		if _, err := buildSplits(f.workspace, 1000, &models.TransactionSplitInput{
+			Method:       models.SplitEqual,
+			Participants: participants,
+		}); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestDistributeRemainder(t *testing.T) {
+	shares := []money.Amount{1, 1, 1}
+	distributeRemainder(shares, 8)
+	if want := []money.Amount{3, 3, 2}; !reflect.DeepEqual(shares, want) {
+		t.Errorf("shares = %v, want %v", shares, want)
+	}
+}
+
+func TestSettleUp(t *testing.T) {
+	alice := participantKey{UserID: 1}
+	bob := participantKey{UserID: 2}
+	carol := participantKey{ContactID: 1}
+	dave := participantKey{ContactID: 2}
+
+	payments := settleUp(map[participantKey]money.Amount{
+		alice:       600,
+		bob:         -100,
+		carol:       -400,
+		dave:        -100,
+		{UserID: 3}: 0,
+	})
+
+	want := []payment{
+		{from: carol, to: alice, amount: 400},
+		{from: dave, to: alice, amount: 100},
+		{from: bob, to: alice, amount: 100},
+	}
+	if !reflect.DeepEqual(payments, want) {
+		t.Errorf("payments = %+v, want %+v", payments, want)
+	}
+}
+
+func TestSettleUpSplitsLargeDebts(t *testing.T) {
+	alice := participantKey{UserID: 1}
+	bob := participantKey{UserID: 2}
+	carol := participantKey{ContactID: 1}
+
+	payments := settleUp(map[participantKey]money.Amount{
+		alice: 300,
+		bob:   200,
+		carol: -500,
+	})
+
+	want := []payment{
+		{from: carol, to: alice, amount: 300},
+		{from: carol, to: bob, amount: 200},
+	}
+	if !reflect.DeepEqual(payments, want) {
+		t.Errorf("payments = %+v, want %+v", payments, want)
+	}
+
+	// Every balance is settled exactly.
+	net := map[participantKey]money.Amount{alice: 300, bob: 200, carol: -500}
+	for _, p := range payments {
+		net[p.from] += p.amount
+		net[p.to] -= p.amount
+	}
+	for key, amount := range net {
+		if amount != 0 {
+			t.Errorf("%+v left with %d", key, amount)
+		}
+	}
+}
\ No newline at end of file
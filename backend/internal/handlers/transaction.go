@@ -6,12 +6,14 @@ import (
 
 	"expense-tracker/internal/database"
 	"expense-tracker/internal/models"
+	"expense-tracker/pkg/money"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func GetTransactions(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 
 	var filter models.TransactionFilter
 	if err := c.ShouldBindQuery(&filter); err != nil {
@@ -27,8 +29,8 @@ func GetTransactions(c *gin.Context) {
 	}
 
 	query := database.DB.Model(&models.Transaction{}).
-		Where("user_id = ?", userID).
-		Preload("Category")
+		Where("workspace_id = ?", workspaceID).
+		Scopes(transactionRelations)
 
 	if filter.StartDate != nil {
 		query = query.Where("date >= ?", filter.StartDate)
@@ -73,13 +75,13 @@ func GetTransactions(c *gin.Context) {
 }
 
 func GetTransaction(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 	transactionID := c.Param("id")
 
 	var transaction models.Transaction
 
-	if err := database.DB.Where("id = ? AND user_id = ?", transactionID, userID).
-		Preload("Category").
+	if err := database.DB.Where("id = ? AND workspace_id = ?", transactionID, workspaceID).
+		Scopes(transactionRelations).
 		First(&transaction).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
@@ -90,6 +92,7 @@ func GetTransaction(c *gin.Context) {
 
 func CreateTransaction(c *gin.Context) {
 	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 
 	var input models.TransactionInput
 
@@ -99,12 +102,17 @@ func CreateTransaction(c *gin.Context) {
 	}
 
 	var category models.Category
-	if err := database.DB.Where("id = ? AND (user_id IS NULL OR user_id = ?)", input.CategoryID, userID).
+	if err := database.DB.Where("id = ? AND (workspace_id IS NULL OR workspace_id = ?)", input.CategoryID, workspaceID).
 		First(&category).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
 		return
 	}
 
+	splits, ok := splitsFromInput(c, workspaceID.(uint), &input)
+	if !ok {
+		return
+	}
+
 	transaction := models.Transaction{
 		Amount:      input.Amount,
 		Description: input.Description,
@@ -112,6 +120,8 @@ func CreateTransaction(c *gin.Context) {
 		Type:        input.Type,
 		CategoryID:  input.CategoryID,
 		UserID:      userID.(uint),
+		WorkspaceID: workspaceID.(uint),
+		Splits:      splits,
 	}
 
 	if err := database.DB.Create(&transaction).Error; err != nil {
@@ -119,23 +129,30 @@ func CreateTransaction(c *gin.Context) {
 		return
 	}
 
-	database.DB.Preload("Category").First(&transaction, transaction.ID)
+	database.DB.Scopes(transactionRelations).First(&transaction, transaction.ID)
 
 	c.JSON(http.StatusCreated, transaction.ToResponse())
 }
 
 func UpdateTransaction(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 	transactionID := c.Param("id")
 
 	var transaction models.Transaction
 
-	if err := database.DB.Where("id = ? AND user_id = ?", transactionID, userID).
+	if err := database.DB.Where("id = ? AND workspace_id = ?", transactionID, workspaceID).
 		First(&transaction).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
 	}
 
+	var settlements int64
+	database.DB.Model(&models.Settlement{}).Where("transaction_id = ?", transaction.ID).Count(&settlements)
+	if settlements > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Settlements cannot be edited, delete and record them again"})
+		return
+	}
+
 	var input models.TransactionInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -144,35 +161,48 @@ func UpdateTransaction(c *gin.Context) {
 	}
 
 	var category models.Category
-	if err := database.DB.Where("id = ? AND (user_id IS NULL OR user_id = ?)", input.CategoryID, userID).
+	if err := database.DB.Where("id = ? AND (workspace_id IS NULL OR workspace_id = ?)", input.CategoryID, workspaceID).
 		First(&category).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
 		return
 	}
 
+	splits, ok := splitsFromInput(c, workspaceID.(uint), &input)
+	if !ok {
+		return
+	}
+
 	transaction.Amount = input.Amount
 	transaction.Description = input.Description
 	transaction.Date = input.Date
 	transaction.Type = input.Type
 	transaction.CategoryID = input.CategoryID
+	transaction.Splits = splits
 
-	if err := database.DB.Save(&transaction).Error; err != nil {
+	// The split, or its absence, replaces the previous one.
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("transaction_id = ?", transaction.ID).Delete(&models.TransactionSplit{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&transaction).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
 		return
 	}
 
-	database.DB.Preload("Category").First(&transaction, transaction.ID)
+	database.DB.Scopes(transactionRelations).First(&transaction, transaction.ID)
 
 	c.JSON(http.StatusOK, transaction.ToResponse())
 }
 
 func DeleteTransaction(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 	transactionID := c.Param("id")
 
 	var transaction models.Transaction
 
-	if err := database.DB.Where("id = ? AND user_id = ?", transactionID, userID).
+	if err := database.DB.Where("id = ? AND workspace_id = ?", transactionID, workspaceID).
 		First(&transaction).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
@@ -187,7 +217,7 @@ func DeleteTransaction(c *gin.Context) {
 }
 
 func GetMonthlyReport(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 
 	yearStr := c.DefaultQuery("year", time.Now().Format("2006"))
 	monthStr := c.DefaultQuery("month", time.Now().Format("01"))
@@ -198,28 +228,28 @@ func GetMonthlyReport(c *gin.Context) {
 	startDate, _ := time.Parse("2006-01", year+"-"+month)
 	endDate := startDate.AddDate(0, 1, 0).Add(-time.Second)
 
-	var totalIncome float64
+	var totalIncome money.Amount
 	database.DB.Model(&models.Transaction{}).
-		Where("user_id = ? AND type = ? AND date >= ? AND date <= ?", userID, "income", startDate, endDate).
+		Where("workspace_id = ? AND type = ? AND date >= ? AND date <= ?", workspaceID, "income", startDate, endDate).
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&totalIncome)
 
-	var totalExpense float64
+	var totalExpense money.Amount
 	database.DB.Model(&models.Transaction{}).
-		Where("user_id = ? AND type = ? AND date >= ? AND date <= ?", userID, "expense", startDate, endDate).
+		Where("workspace_id = ? AND type = ? AND date >= ? AND date <= ?", workspaceID, "expense", startDate, endDate).
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&totalExpense)
 
 	var transactionCount int64
 	database.DB.Model(&models.Transaction{}).
-		Where("user_id = ? AND date >= ? AND date <= ?", userID, startDate, endDate).
+		Where("workspace_id = ? AND date >= ? AND date <= ?", workspaceID, startDate, endDate).
 		Count(&transactionCount)
 
 	type CategoryBreakdown struct {
 		CategoryID   uint
 		CategoryName string
 		CategoryIcon string
-		TotalAmount  float64
+		TotalAmount  money.Amount
 		Count        int
 	}
 
@@ -227,14 +257,18 @@ func GetMonthlyReport(c *gin.Context) {
 	database.DB.Model(&models.Transaction{}).
 		Select("category_id, categories.name as category_name, categories.icon as category_icon, SUM(amount) as total_amount, COUNT(*) as count").
 		Joins("JOIN categories ON categories.id = transactions.category_id").
-		Where("transactions.user_id = ? AND transactions.date >= ? AND transactions.date <= ?", userID, startDate, endDate).
+		Where("transactions.workspace_id = ? AND transactions.date >= ? AND transactions.date <= ?", workspaceID, startDate, endDate).
 		Group("category_id, categories.name, categories.icon").
 		Order("total_amount DESC").
 		Scan(&categoryBreakdown)
 
 	var categorySum []models.CategorySummary
+	totalAmount := totalIncome + totalExpense
 	for _, cb := range categoryBreakdown {
-		percentage := (cb.TotalAmount / (totalIncome + totalExpense)) * 100
+		percentage := float64(0)
+		if totalAmount > 0 {
+			percentage = float64(cb.TotalAmount) / float64(totalAmount) * 100
+		}
 		categorySum = append(categorySum, models.CategorySummary{
 			CategoryID:   cb.CategoryID,
 			CategoryName: cb.CategoryName,
@@ -260,28 +294,28 @@ func GetMonthlyReport(c *gin.Context) {
 }
 
 func GetDashboardStats(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	workspaceID, _ := c.Get("workspaceID")
 
-	var totalIncome float64
+	var totalIncome money.Amount
 	database.DB.Model(&models.Transaction{}).
-		Where("user_id = ? AND type = ?", userID, "income").
+		Where("workspace_id = ? AND type = ?", workspaceID, "income").
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&totalIncome)
 
-	var totalExpense float64
+	var totalExpense money.Amount
 	database.DB.Model(&models.Transaction{}).
-		Where("user_id = ? AND type = ?", userID, "expense").
+		Where("workspace_id = ? AND type = ?", workspaceID, "expense").
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&totalExpense)
 
 	var transactionCount int64
 	database.DB.Model(&models.Transaction{}).
-		Where("user_id = ?", userID).
+		Where("workspace_id = ?", workspaceID).
 		Count(&transactionCount)
 
 	var recentTransactions []models.Transaction
-	database.DB.Where("user_id = ?", userID).
-		Preload("Category").
+	database.DB.Where("workspace_id = ?", workspaceID).
+		Scopes(transactionRelations).
 		Order("date DESC, created_at DESC").
 		Limit(5).
 		Find(&recentTransactions)
@@ -295,7 +329,7 @@ func GetDashboardStats(c *gin.Context) {
 		CategoryID   uint
 		CategoryName string
 		CategoryIcon string
-		TotalAmount  float64
+		TotalAmount  money.Amount
 		Count        int
 	}
 
@@ -303,7 +337,7 @@ func GetDashboardStats(c *gin.Context) {
 	database.DB.Model(&models.Transaction{}).
 		Select("category_id, categories.name as category_name, categories.icon as category_icon, SUM(amount) as total_amount, COUNT(*) as count").
 		Joins("JOIN categories ON categories.id = transactions.category_id").
-		Where("transactions.user_id = ?", userID).
+		Where("transactions.workspace_id = ?", workspaceID).
 		Group("category_id, categories.name, categories.icon").
 		Order("total_amount DESC").
 		Limit(10).
@@ -314,7 +348,7 @@ func GetDashboardStats(c *gin.Context) {
 	for _, cb := range categoryBreakdown {
 		percentage := float64(0)
 		if totalAmount > 0 {
-			percentage = (cb.TotalAmount / totalAmount) * 100
+			percentage = float64(cb.TotalAmount) / float64(totalAmount) * 100
 		}
 		categorySum = append(categorySum, models.CategorySummary{
 			CategoryID:   cb.CategoryID,
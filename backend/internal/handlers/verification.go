@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/mailer"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// VerifyEmail marks the user's email as verified using a token from the
+// verification email.
+func VerifyEmail(c *gin.Context) {
+	var input models.VerifyEmailInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var token models.EmailVerificationToken
+	if err := database.DB.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", utils.HashToken(input.Token), time.Now()).
+		First(&token).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	now := time.Now()
+	result := database.DB.Model(&models.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	if token.Purpose == models.EmailTokenChange {
+		if err := applyEmailChange(&token); err != nil {
+			respondEmailChangeError(c, err)
+			return
+		}
+
+		audit.Record(c, models.EventEmailChanged, token.UserID, "new email "+token.Email)
+		c.JSON(http.StatusOK, gin.H{"message": "Email address changed successfully"})
+		return
+	}
+
+	// The token only vouches for the address it was sent to.
+	result = database.DB.Model(&models.User{}).
+		Where("id = ? AND email = ?", token.UserID, token.Email).
+		Update("email_verified_at", now)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	audit.Record(c, models.EventEmailVerified, token.UserID, "")
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification sends a fresh verification email to the current user.
+func ResendVerification(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email already verified"})
+		return
+	}
+
+	if err := sendVerificationEmail(database.DB, &user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// sendVerificationEmail invalidates any pending verification tokens for the
+// user and emails a new one, storing the token through db.
+func sendVerificationEmail(db *gorm.DB, user *models.User) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	db.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, models.EmailTokenVerify).
+		Update("used_at", now)
+
+	ttl := utils.EmailVerificationTTL()
+	verification := models.EmailVerificationToken{
+		TokenHash: utils.HashToken(token),
+		Email:     user.Email,
+		Purpose:   models.EmailTokenVerify,
+		ExpiresAt: now.Add(ttl),
+		UserID:    user.ID,
+	}
+	if err := db.Create(&verification).Error; err != nil {
+		return err
+	}
+
+	sendEmail(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %s.\n\n%s\n",
+			user.Name, ttl, frontendLink("/verify-email", token)),
+	})
+
+	return nil
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/jobs"
+	"expense-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetWorkspaces lists the workspaces the current user is a member of.
+func GetWorkspaces(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var members []models.WorkspaceMember
+	if err := database.DB.Joins("Workspace").
+		Where("workspace_members.user_id = ?", userID).
+		Order(`"Workspace"."personal" DESC, "Workspace"."name"`).
+		Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch workspaces"})
+		return
+	}
+
+	response := []models.WorkspaceResponse{}
+	for _, member := range members {
+		response = append(response, member.ToWorkspaceResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateWorkspace creates a shared workspace owned by the current user.
+func CreateWorkspace(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input models.WorkspaceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace := models.Workspace{Name: input.Name}
+	member := models.WorkspaceMember{UserID: userID.(uint), Role: models.WorkspaceOwner}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&workspace).Error; err != nil {
+			return err
+		}
+		member.WorkspaceID = workspace.ID
+		return tx.Create(&member).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create workspace"})
+		return
+	}
+
+	member.Workspace = &workspace
+	c.JSON(http.StatusCreated, member.ToWorkspaceResponse())
+}
+
+// GetWorkspace returns a workspace with its members.
+func GetWorkspace(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	membership, ok := findWorkspaceMembership(c, userID.(uint), models.WorkspaceViewer)
+	if !ok {
+		return
+	}
+
+	var members []models.WorkspaceMember
+	if err := database.DB.Preload("User").
+		Where("workspace_id = ?", membership.WorkspaceID).
+		Order("created_at").
+		Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch workspace members"})
+		return
+	}
+
+	response := models.WorkspaceDetailResponse{
+		WorkspaceResponse: membership.ToWorkspaceResponse(),
+		Members:           []models.WorkspaceMemberResponse{},
+	}
+	for _, member := range members {
+		response.Members = append(response.Members, member.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateWorkspace renames a workspace. Owners only.
+func UpdateWorkspace(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	membership, ok := findWorkspaceMembership(c, userID.(uint), models.WorkspaceOwner)
+	if !ok {
+		return
+	}
+
+	var input models.WorkspaceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Model(membership.Workspace).Update("name", input.Name).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update workspace"})
+		return
+	}
+	membership.Workspace.Name = input.Name
+
+	c.JSON(http.StatusOK, membership.ToWorkspaceResponse())
+}
+
+// DeleteWorkspace permanently deletes a shared workspace with everything in
+// it. Owners only; personal workspaces cannot be deleted.
+func DeleteWorkspace(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	membership, ok := findWorkspaceMembership(c, userID.(uint), models.WorkspaceOwner)
+	if !ok {
+		return
+	}
+
+	if membership.Workspace.Personal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Personal workspaces cannot be deleted"})
+		return
+	}
+
+	var members []models.WorkspaceMember
+	if err := database.DB.Where("workspace_id = ?", membership.WorkspaceID).Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete workspace"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return jobs.DeleteWorkspace(tx, membership.WorkspaceID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete workspace"})
+		return
+	}
+
+	// Every member loses the ledger, so it goes into each of their logs.
+	details := fmt.Sprintf("workspace %d %s", membership.WorkspaceID, membership.Workspace.Name)
+	for _, member := range members {
+		audit.RecordActor(c, models.EventWorkspaceDeleted, member.UserID, userID.(uint), details)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Workspace deleted successfully"})
+}
+
+// AddWorkspaceMember shares a workspace with a registered user. Owners only.
+func AddWorkspaceMember(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	membership, ok := findWorkspaceMembership(c, userID.(uint), models.WorkspaceOwner)
+	if !ok {
+		return
+	}
+
+	if membership.Workspace.Personal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Personal workspaces cannot be shared"})
+		return
+	}
+
+	var input models.WorkspaceMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	member := models.WorkspaceMember{WorkspaceID: membership.WorkspaceID, UserID: user.ID, Role: input.Role}
+	if err := database.DB.Create(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "User is already a member of this workspace"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+
+	audit.RecordActor(c, models.EventWorkspaceMemberAdded, user.ID, userID.(uint),
+		fmt.Sprintf("workspace %d %s as %s", membership.WorkspaceID, membership.Workspace.Name, member.Role))
+
+	member.User = &user
+	c.JSON(http.StatusCreated, member.ToResponse())
+}
+
+// UpdateWorkspaceMember changes a member's role. Owners only; a workspace
+// always keeps at least one owner.
+func UpdateWorkspaceMember(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	membership, ok := findWorkspaceMembership(c, userID.(uint), models.WorkspaceOwner)
+	if !ok {
+		return
+	}
+
+	var input models.WorkspaceMemberRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var member models.WorkspaceMember
+	if err := database.DB.Preload("User").
+		Where("workspace_id = ? AND user_id = ?", membership.WorkspaceID, c.Param("userId")).
+		First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	if member.Role == models.WorkspaceOwner && input.Role != models.WorkspaceOwner && isLastWorkspaceOwner(&member) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A workspace needs at least one owner"})
+		return
+	}
+
+	if err := database.DB.Model(&member).Update("role", input.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+	member.Role = input.Role
+
+	audit.RecordActor(c, models.EventWorkspaceRoleChanged, member.UserID, userID.(uint),
+		fmt.Sprintf("workspace %d %s as %s", membership.WorkspaceID, membership.Workspace.Name, member.Role))
+	c.JSON(http.StatusOK, member.ToResponse())
+}
+
+// RemoveWorkspaceMember removes a member. Owners can remove anyone, other
+// members only themselves; the last owner cannot leave.
+func RemoveWorkspaceMember(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	membership, ok := findWorkspaceMembership(c, userID.(uint), models.WorkspaceViewer)
+	if !ok {
+		return
+	}
+
+	var member models.WorkspaceMember
+	if err := database.DB.Where("workspace_id = ? AND user_id = ?", membership.WorkspaceID, c.Param("userId")).
+		First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	if member.UserID != userID.(uint) && membership.Role != models.WorkspaceOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your workspace role does not allow this"})
+		return
+	}
+
+	if membership.Workspace.Personal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot leave your personal workspace"})
+		return
+	}
+
+	if member.Role == models.WorkspaceOwner && isLastWorkspaceOwner(&member) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A workspace needs at least one owner"})
+		return
+	}
+
+	if err := database.DB.Delete(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	audit.RecordActor(c, models.EventWorkspaceMemberRemoved, member.UserID, userID.(uint),
+		fmt.Sprintf("workspace %d %s", membership.WorkspaceID, membership.Workspace.Name))
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// findWorkspaceMembership loads the current user's membership of the
+// workspace in the :id parameter and checks it grants at least role. It
+// responds and returns false otherwise.
+func findWorkspaceMembership(c *gin.Context, userID uint, role string) (*models.WorkspaceMember, bool) {
+	var membership models.WorkspaceMember
+	if err := database.DB.Preload("Workspace").
+		Where("workspace_id = ? AND user_id = ?", c.Param("id"), userID).
+		First(&membership).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return nil, false
+	}
+
+	if !models.WorkspaceRoleAtLeast(membership.Role, role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your workspace role does not allow this"})
+		return nil, false
+	}
+
+	return &membership, true
+}
+
+func isLastWorkspaceOwner(member *models.WorkspaceMember) bool {
+	var owners int64
+	database.DB.Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND role = ?", member.WorkspaceID, models.WorkspaceOwner).
+		Count(&owners)
+	return owners <= 1
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/middleware"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/testdb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roleFixture is a shared workspace with one member of each role and a
+// registered user outside it.
+type roleFixture struct {
+	workspace uint
+	owner     uint
+	editor    uint
+	viewer    uint
+	outsider  uint
+}
+
+func newRoleFixture(t *testing.T) roleFixture {
+	t.Helper()
+	testdb.Open(t)
+
+	owner := testdb.CreateUser(t, "owner@example.com")
+	editor := testdb.CreateUser(t, "editor@example.com")
+	viewer := testdb.CreateUser(t, "viewer@example.com")
+	outsider := testdb.CreateUser(t, "outsider@example.com")
+
+	workspace := models.Workspace{Name: "Household"}
+	if err := database.DB.Create(&workspace).Error; err != nil {
+		t.Fatal(err)
+	}
+	roles := map[uint]string{owner.ID: models.WorkspaceOwner, editor.ID: models.WorkspaceEditor, viewer.ID: models.WorkspaceViewer}
+	for userID, role := range roles {
+		member := models.WorkspaceMember{WorkspaceID: workspace.ID, UserID: userID, Role: role}
+		if err := database.DB.Create(&member).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return roleFixture{workspace: workspace.ID, owner: owner.ID, editor: editor.ID, viewer: viewer.ID, outsider: outsider.ID}
+}
+
+// router wires the workspace routes the way the server does, acting as the
+// given user.
+func (f roleFixture) router(userID uint) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Next()
+	})
+
+	router.GET("/workspaces/:id", GetWorkspace)
+	router.DELETE("/workspaces/:id", DeleteWorkspace)
+	router.POST("/workspaces/:id/members", AddWorkspaceMember)
+	router.PATCH("/workspaces/:id/members/:userId", UpdateWorkspaceMember)
+	router.DELETE("/workspaces/:id/members/:userId", RemoveWorkspaceMember)
+
+	data := router.Group("")
+	data.Use(middleware.ResolveWorkspace())
+	data.GET("/contacts", GetContacts)
+	data.POST("/contacts", middleware.RequireWorkspaceRole(models.WorkspaceEditor), CreateContact)
+	data.POST("/transactions", middleware.RequireWorkspaceRole(models.WorkspaceEditor), CreateTransaction)
+
+	return router
+}
+
+func (f roleFixture) path(format string, args ...interface{}) string {
+	return fmt.Sprintf(format, append([]interface{}{f.workspace}, args...)...)
+}
+
+func (f roleFixture) role(t *testing.T, userID uint) string {
+	t.Helper()
+
+	var member models.WorkspaceMember
+	if err := database.DB.Where("workspace_id = ? AND user_id = ?", f.workspace, userID).First(&member).Error; err != nil {
+		return ""
+	}
+	return member.Role
+}
+
+func TestWorkspaceViewerCannotWrite(t *testing.T) {
+	f := newRoleFixture(t)
+	viewer := f.router(f.viewer)
+
+	if w := doJSON(t, viewer, http.MethodGet, f.path("/contacts?workspace_id=%d"), nil, nil); w.Code != http.StatusOK {
+		t.Errorf("viewer list contacts: got %d %s", w.Code, w.Body)
+	}
+	if w := doJSON(t, viewer, http.MethodPost, f.path("/contacts?workspace_id=%d"), models.ContactInput{Name: "Carol"}, nil); w.Code != http.StatusForbidden {
+		t.Errorf("viewer create contact: got %d", w.Code)
+	}
+	if w := doJSON(t, viewer, http.MethodPost, f.path("/transactions?workspace_id=%d"), gin.H{"amount": 1000}, nil); w.Code != http.StatusForbidden {
+		t.Errorf("viewer create transaction: got %d", w.Code)
+	}
+
+	var contacts int64
+	database.DB.Model(&models.Contact{}).Where("workspace_id = ?", f.workspace).Count(&contacts)
+	if contacts != 0 {
+		t.Errorf("%d contacts after the viewer's request, want 0", contacts)
+	}
+
+	if w := doJSON(t, f.router(f.editor), http.MethodPost, f.path("/contacts?workspace_id=%d"), models.ContactInput{Name: "Carol"}, nil); w.Code != http.StatusCreated {
+		t.Errorf("editor create contact: got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestWorkspaceEditorCannotManageMembers(t *testing.T) {
+	f := newRoleFixture(t)
+	editor := f.router(f.editor)
+
+	add := models.WorkspaceMemberInput{Email: "outsider@example.com", Role: models.WorkspaceEditor}
+	if w := doJSON(t, editor, http.MethodPost, f.path("/workspaces/%d/members"), add, nil); w.Code != http.StatusForbidden {
+		t.Errorf("editor add member: got %d", w.Code)
+	}
+
+	promote := models.WorkspaceMemberRoleInput{Role: models.WorkspaceOwner}
+	for _, userID := range []uint{f.viewer, f.editor} {
+		if w := doJSON(t, editor, http.MethodPatch, f.path("/workspaces/%d/members/%d", userID), promote, nil); w.Code != http.StatusForbidden {
+			t.Errorf("editor promote member %d: got %d", userID, w.Code)
+		}
+	}
+
+	for _, userID := range []uint{f.viewer, f.owner} {
+		if w := doJSON(t, editor, http.MethodDelete, f.path("/workspaces/%d/members/%d", userID), nil, nil); w.Code != http.StatusForbidden {
+			t.Errorf("editor remove member %d: got %d", userID, w.Code)
+		}
+	}
+
+	if w := doJSON(t, editor, http.MethodDelete, f.path("/workspaces/%d"), nil, nil); w.Code != http.StatusForbidden {
+		t.Errorf("editor delete workspace: got %d", w.Code)
+	}
+
+	want := map[uint]string{f.owner: models.WorkspaceOwner, f.editor: models.WorkspaceEditor, f.viewer: models.WorkspaceViewer, f.outsider: ""}
+	for userID, role := range want {
+		if got := f.role(t, userID); got != role {
+			t.Errorf("user %d has role %q, want %q", userID, got, role)
+		}
+	}
+
+	// Members can still leave on their own.
+	if w := doJSON(t, editor, http.MethodDelete, f.path("/workspaces/%d/members/%d", f.editor), nil, nil); w.Code != http.StatusOK {
+		t.Errorf("editor leave: got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestWorkspaceNonMemberGetsNotFound(t *testing.T) {
+	f := newRoleFixture(t)
+	outsider := f.router(f.outsider)
+
+	tests := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodGet, f.path("/contacts?workspace_id=%d"), nil},
+		{http.MethodPost, f.path("/contacts?workspace_id=%d"), models.ContactInput{Name: "Carol"}},
+		{http.MethodGet, f.path("/workspaces/%d"), nil},
+		{http.MethodDelete, f.path("/workspaces/%d"), nil},
+		{http.MethodPost, f.path("/workspaces/%d/members"), models.WorkspaceMemberInput{Email: "outsider@example.com", Role: models.WorkspaceOwner}},
+		{http.MethodPatch, f.path("/workspaces/%d/members/%d", f.viewer), models.WorkspaceMemberRoleInput{Role: models.WorkspaceOwner}},
+		{http.MethodDelete, f.path("/workspaces/%d/members/%d", f.viewer), nil},
+	}
+	for _, tt := range tests {
+		if w := doJSON(t, outsider, tt.method, tt.path, tt.body, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s %s: got %d", tt.method, tt.path, w.Code)
+		}
+	}
+
+	if got := f.role(t, f.outsider); got != "" {
+		t.Errorf("outsider joined the workspace as %q", got)
+	}
+	if got := f.role(t, f.viewer); got != models.WorkspaceViewer {
+		t.Errorf("viewer has role %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// moneyTables lists the tables whose amount column used to hold decimal
+// values and now holds minor units.
+var moneyTables = []string{"transactions", "transaction_splits", "settlements"}
+
+// MigrateMoneyColumns converts amount columns still holding decimal values
+// to bigint minor units, rounding to the nearest cent. It must run before
+// AutoMigrate, which would change the column type without scaling the
+// values. It is safe to run on every start and returns the converted tables.
+func MigrateMoneyColumns(db *gorm.DB) ([]string, error) {
+	var converted []string
+	for _, table := range moneyTables {
+		if !db.Migrator().HasTable(table) {
+			continue
+		}
+
+		columns, err := db.Migrator().ColumnTypes(table)
+		if err != nil {
+			return converted, err
+		}
+		for _, column := range columns {
+			if column.Name() != "amount" || isBigint(column.DatabaseTypeName()) {
+				continue
+			}
+
+			sql := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN amount TYPE bigint USING ROUND(amount * 100)", table)
+			if err := db.Exec(sql).Error; err != nil {
+				return converted, fmt.Errorf("converting %s.amount: %w", table, err)
+			}
+			converted = append(converted, table)
+		}
+	}
+	return converted, nil
+}
+
+func isBigint(typeName string) bool {
+	switch strings.ToLower(typeName) {
+	case "int8", "bigint":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// userOwnedModels lists every table with a user_id column that must be wiped
+// when an account is hard-deleted. New user-owned models belong here.
+// Categories and transactions in shared workspaces, including ones the user
+// was removed from, are handed over by releaseWorkspaces first and survive.
+var userOwnedModels = []interface{}{
+	&models.Transaction{},
+	&models.Category{},
+	&models.Session{},
+	&models.RefreshToken{},
+	&models.RevokedToken{},
+	&models.PasswordResetToken{},
+	&models.EmailVerificationToken{},
+	&models.RecoveryCode{},
+	&models.APIToken{},
+	&models.SecurityEvent{},
+	&models.Identity{},
+	&models.WebAuthnCredential{},
+	&models.WebAuthnSession{},
+	&models.WorkspaceMember{},
+}
+
+// PurgeDeletedAccounts permanently deletes every account whose scheduled
+// deletion time has passed, along with all rows it owns. It returns how many
+// accounts were removed.
+func PurgeDeletedAccounts(db *gorm.DB) (int, error) {
+	var users []models.User
+	if err := db.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", time.Now()).
+		Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, user := range users {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := releaseWorkspaces(tx, user.ID); err != nil {
+				return err
+			}
+			for _, model := range userOwnedModels {
+				if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+					return err
+				}
+			}
+			if err := purgeUnownedRows(tx, user); err != nil {
+				return err
+			}
+			return tx.Unscoped().Delete(&models.User{}, user.ID).Error
+		})
+		if err != nil {
+			log.Printf("❌ Failed to purge user %d: %v", user.ID, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// purgeUnownedRows deletes rows that refer to the user without a user_id
+// column: login throttling keyed by their email and pending OIDC account
+// links.
+func purgeUnownedRows(tx *gorm.DB, user models.User) error {
+	if err := tx.Where("throttle_key = ?", auth.AccountThrottleKey(user.Email)).
+		Delete(&models.LoginThrottle{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("link_user_id = ?", user.ID).Delete(&models.OIDCState{}).Error
+}
+
+// StartPurgeLoop runs PurgeDeletedAccounts every interval in the background.
+func StartPurgeLoop(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			purged, err := PurgeDeletedAccounts(db)
+			if err != nil {
+				log.Printf("❌ Account purge failed: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("🗑️  Purged %d deleted account(s)", purged)
+			}
+		}
+	}()
+}
@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/testdb"
+
+	"gorm.io/gorm"
+)
+
+// sharedWorkspace creates a workspace owned by owner with member as editor.
+func sharedWorkspace(t *testing.T, db *gorm.DB, owner, member uint) (*models.Workspace, *models.WorkspaceMember) {
+	t.Helper()
+
+	workspace := models.Workspace{Name: "Household"}
+	if err := db.Create(&workspace).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.WorkspaceMember{WorkspaceID: workspace.ID, UserID: owner, Role: models.WorkspaceOwner}).Error; err != nil {
+		t.Fatal(err)
+	}
+	membership := models.WorkspaceMember{WorkspaceID: workspace.ID, UserID: member, Role: models.WorkspaceEditor}
+	if err := db.Create(&membership).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &workspace, &membership
+}
+
+func createEntries(t *testing.T, db *gorm.DB, workspaceID, userID uint) (*models.Category, *models.Transaction) {
+	t.Helper()
+
+	category := models.Category{Name: "Groceries", Type: "expense", UserID: &userID, WorkspaceID: &workspaceID}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatal(err)
+	}
+	transaction := models.Transaction{
+		Amount: 1250, Description: "Market", Date: time.Now(), Type: "expense",
+		UserID: userID, WorkspaceID: workspaceID, CategoryID: category.ID,
+	}
+	if err := db.Create(&transaction).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &category, &transaction
+}
+
+func scheduleDeletion(t *testing.T, db *gorm.DB, user *models.User) {
+	t.Helper()
+	if err := db.Model(user).Update("deletion_scheduled_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPurgeHandsOverSharedRows(t *testing.T) {
+	db := testdb.Open(t)
+	owner := testdb.CreateUser(t, "owner@example.com")
+	leaving := testdb.CreateUser(t, "leaving@example.com")
+
+	workspace, _ := sharedWorkspace(t, db, owner.ID, leaving.ID)
+	category, transaction := createEntries(t, db, workspace.ID, leaving.ID)
+
+	scheduleDeletion(t, db, leaving)
+	if purged, err := PurgeDeletedAccounts(db); err != nil || purged != 1 {
+		t.Fatalf("purged %d, %v", purged, err)
+	}
+
+	db.First(category, category.ID)
+	db.First(transaction, transaction.ID)
+	if category.UserID == nil || *category.UserID != owner.ID || transaction.UserID != owner.ID {
+		t.Errorf("rows not handed to the owner: category %v, transaction %d", category.UserID, transaction.UserID)
+	}
+	if transaction.PayerUserID != leaving.ID {
+		t.Errorf("payer changed to %d", transaction.PayerUserID)
+	}
+}
+
+func TestPurgeKeepsRowsOfRemovedMember(t *testing.T) {
+	db := testdb.Open(t)
+	owner := testdb.CreateUser(t, "owner@example.com")
+	removed := testdb.CreateUser(t, "removed@example.com")
+
+	workspace, membership := sharedWorkspace(t, db, owner.ID, removed.ID)
+	category, transaction := createEntries(t, db, workspace.ID, removed.ID)
+
+	// The owner's own transaction uses the removed member's category.
+	ownerTransaction := models.Transaction{
+		Amount: 800, Description: "Bakery", Date: time.Now(), Type: "expense",
+		UserID: owner.ID, WorkspaceID: workspace.ID, CategoryID: category.ID,
+	}
+	if err := db.Create(&ownerTransaction).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Delete(membership).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	scheduleDeletion(t, db, removed)
+	if purged, err := PurgeDeletedAccounts(db); err != nil || purged != 1 {
+		t.Fatalf("purged %d, %v", purged, err)
+	}
+
+	if err := db.First(category, category.ID).Error; err != nil {
+		t.Fatalf("category deleted: %v", err)
+	}
+	if err := db.First(transaction, transaction.ID).Error; err != nil {
+		t.Fatalf("transaction deleted: %v", err)
+	}
+	if *category.UserID != owner.ID || transaction.UserID != owner.ID {
+		t.Errorf("rows not handed to the owner: category %d, transaction %d", *category.UserID, transaction.UserID)
+	}
+
+	var workspaces int64
+	db.Model(&models.Workspace{}).Where("id = ?", workspace.ID).Count(&workspaces)
+	if workspaces != 1 {
+		t.Error("the shared workspace was deleted")
+	}
+	var users int64
+	db.Model(&models.User{}).Where("id = ?", removed.ID).Count(&users)
+	if users != 0 {
+		t.Error("the account was not purged")
+	}
+}
+
+func TestPurgeDeletesSoleWorkspaces(t *testing.T) {
+	db := testdb.Open(t)
+	user := testdb.CreateUser(t, "alone@example.com")
+
+	var personal models.WorkspaceMember
+	if err := db.Where("user_id = ?", user.ID).First(&personal).Error; err != nil {
+		t.Fatal(err)
+	}
+	category, transaction := createEntries(t, db, personal.WorkspaceID, user.ID)
+
+	scheduleDeletion(t, db, user)
+	if purged, err := PurgeDeletedAccounts(db); err != nil || purged != 1 {
+		t.Fatalf("purged %d, %v", purged, err)
+	}
+
+	var rows int64
+	db.Unscoped().Model(&models.Transaction{}).Where("id = ?", transaction.ID).Count(&rows)
+	if rows != 0 {
+		t.Error("transaction survived")
+	}
+	db.Unscoped().Model(&models.Category{}).Where("id = ?", category.ID).Count(&rows)
+	if rows != 0 {
+		t.Error("category survived")
+	}
+	db.Model(&models.Workspace{}).Where("id = ?", personal.WorkspaceID).Count(&rows)
+	if rows != 0 {
+		t.Error("personal workspace survived")
+	}
+}
@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"expense-tracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MigrateTransactionPayers fills in the payer of transactions created before
+// payers were recorded separately. It is safe to run on every start and
+// returns how many transactions were updated.
+func MigrateTransactionPayers(db *gorm.DB) (int64, error) {
+	result := db.Unscoped().Model(&models.Transaction{}).
+		Where("payer_user_id = 0").
+		Update("payer_user_id", gorm.Expr("user_id"))
+	return result.RowsAffected, result.Error
+}
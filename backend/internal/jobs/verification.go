@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"expense-tracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MigrateEmailVerification marks accounts created before email verification
+// existed as verified, so REQUIRE_EMAIL_VERIFICATION does not lock them out.
+// Every account registered since then was sent a verification token, so
+// unverified accounts without one predate the feature. It is safe to run on
+// every start and returns how many accounts were marked.
+func MigrateEmailVerification(db *gorm.DB) (int64, error) {
+	result := db.Unscoped().Model(&models.User{}).
+		Where("email_verified_at IS NULL").
+		Where("NOT EXISTS (SELECT 1 FROM email_verification_tokens WHERE email_verification_tokens.user_id = users.id AND email_verification_tokens.purpose = ?)", models.EmailTokenVerify).
+		Update("email_verified_at", gorm.Expr("created_at"))
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"log"
+
+	"expense-tracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MigratePersonalWorkspaces gives every user without a personal workspace
+// one and moves the categories and transactions they owned before
+// workspaces existed into it. It is safe to run on every start and returns
+// how many users were migrated.
+func MigratePersonalWorkspaces(db *gorm.DB) (int, error) {
+	var users []models.User
+	if err := db.Unscoped().
+		Where(`NOT EXISTS (SELECT 1 FROM workspace_members
+			JOIN workspaces ON workspaces.id = workspace_members.workspace_id
+			WHERE workspace_members.user_id = users.id AND workspaces.personal)`).
+		Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, user := range users {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			workspace, err := models.CreatePersonalWorkspace(tx, user.ID)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Unscoped().Model(&models.Category{}).
+				Where("user_id = ? AND workspace_id IS NULL", user.ID).
+				Update("workspace_id", workspace.ID).Error; err != nil {
+				return err
+			}
+
+			return tx.Unscoped().Model(&models.Transaction{}).
+				Where("user_id = ? AND workspace_id = 0", user.ID).
+				Update("workspace_id", workspace.ID).Error
+		})
+		if err != nil {
+			log.Printf("❌ Failed to create personal workspace for user %d: %v", user.ID, err)
+			continue
+		}
+		migrated++
+	}
+
+	return migrated, nil
+}
+
+// workspaceOwnedModels lists every table with a workspace_id column, in an
+// order that respects their foreign keys. New workspace-owned models belong
+// here.
+var workspaceOwnedModels = []interface{}{
+	&models.Settlement{},
+	&models.TransactionSplit{},
+	&models.Transaction{},
+	&models.Contact{},
+	&models.Category{},
+	&models.WorkspaceMember{},
+}
+
+// DeleteWorkspace permanently deletes a workspace and everything in it.
+func DeleteWorkspace(tx *gorm.DB, workspaceID uint) error {
+	for _, model := range workspaceOwnedModels {
+		if err := tx.Unscoped().Where("workspace_id = ?", workspaceID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Delete(&models.Workspace{}, workspaceID).Error
+}
+
+// releaseWorkspaces detaches a user who is about to be purged from their
+// workspaces. Workspaces nobody else uses are deleted with their contents.
+// In shared ones the user's categories and transactions are handed to an
+// owner, and the longest-standing member becomes owner if the user was the
+// last one. This includes workspaces the user was removed from, whose rows
+// they still created. Transactions keep their PayerUserID, so split balances
+// are not shifted to the new owner.
+func releaseWorkspaces(tx *gorm.DB, userID uint) error {
+	workspaceIDs, err := userWorkspaceIDs(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, workspaceID := range workspaceIDs {
+		var others []models.WorkspaceMember
+		if err := tx.Where("workspace_id = ? AND user_id <> ?", workspaceID, userID).
+			Order("created_at").
+			Find(&others).Error; err != nil {
+			return err
+		}
+
+		if len(others) == 0 {
+			if err := DeleteWorkspace(tx, workspaceID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		heir := others[0]
+		for _, other := range others {
+			if other.Role == models.WorkspaceOwner {
+				heir = other
+				break
+			}
+		}
+		if heir.Role != models.WorkspaceOwner {
+			if err := tx.Model(&heir).Update("role", models.WorkspaceOwner).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, model := range []interface{}{&models.Transaction{}, &models.Category{}} {
+			if err := tx.Unscoped().Model(model).
+				Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+				Update("user_id", heir.UserID).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// userWorkspaceIDs lists the workspaces the user is a member of or created
+// categories or transactions in.
+func userWorkspaceIDs(tx *gorm.DB, userID uint) ([]uint, error) {
+	var ids []uint
+	if err := tx.Model(&models.WorkspaceMember{}).Where("user_id = ?", userID).
+		Pluck("workspace_id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	for _, model := range []interface{}{&models.Transaction{}, &models.Category{}} {
+		var created []uint
+		if err := tx.Unscoped().Model(model).
+			Where("user_id = ? AND workspace_id IS NOT NULL AND workspace_id <> 0", userID).
+			Distinct().Pluck("workspace_id", &created).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, created...)
+	}
+
+	seen := map[uint]bool{}
+	unique := ids[:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
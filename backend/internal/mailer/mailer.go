@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"os"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Default is the mailer used by the handlers. main replaces it with the
+// result of NewFromEnv.
+var Default Mailer = NewFileMailer("")
+
+// NewFromEnv builds a mailer from MAIL_DRIVER ("smtp" or "file"). The file
+// driver is the default so development never sends real email.
+func NewFromEnv() Mailer {
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		return NewSMTPMailer(
+			os.Getenv("SMTP_HOST"),
+			os.Getenv("SMTP_PORT"),
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			os.Getenv("MAIL_FROM"),
+		)
+	default:
+		return NewFileMailer(os.Getenv("MAIL_FILE"))
+	}
+}
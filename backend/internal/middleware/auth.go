@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strings"
 
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/models"
 	"expense-tracker/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -11,22 +13,28 @@ import (
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+		token, ok := bearerOrCookieToken(c)
+		if !ok {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+		if strings.HasPrefix(token, models.APITokenPrefix) {
+			apiToken, user, err := auth.AuthenticateAPIToken(token)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+				c.Abort()
+				return
+			}
+
+			c.Set("userID", user.ID)
+			c.Set("userEmail", user.Email)
+			c.Set("userRole", user.Role)
+			c.Set("apiTokenScopes", apiToken.ScopeList())
+
+			c.Next()
 			return
 		}
 
-		token := parts[1]
-
 		claims, err := utils.ValidateToken(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
@@ -34,9 +42,58 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if revoked, err := auth.IsTokenRevoked(claims); err != nil || revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		if claims.SessionID != 0 {
+			auth.TouchSession(claims.SessionID, c.ClientIP())
+		}
+
 		c.Set("userID", claims.UserID)
 		c.Set("userEmail", claims.Email)
+		c.Set("userRole", claims.Role)
+		c.Set("claims", claims)
+
+		if claims.ImpersonatorID != 0 {
+			impersonate(c, claims)
+			return
+		}
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// bearerOrCookieToken returns the token from the Authorization header or,
+// with AUTH_COOKIES enabled, from the access token cookie. Cookie-based
+// requests must pass the CSRF check. It aborts the request on failure.
+func bearerOrCookieToken(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		if auth.CookieAuthEnabled() {
+			if token, err := c.Cookie(auth.AccessTokenCookie); err == nil && token != "" {
+				if !auth.ValidCSRF(c) {
+					c.JSON(http.StatusForbidden, gin.H{"error": "Invalid CSRF token"})
+					c.Abort()
+					return "", false
+				}
+				return token, true
+			}
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		c.Abort()
+		return "", false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+		c.Abort()
+		return "", false
+	}
+
+	return parts[1], true
+}
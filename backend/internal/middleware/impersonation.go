@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response headers marking requests made with an impersonation token.
+const (
+	ImpersonatedByHeader    = "X-Impersonated-By"
+	ImpersonationModeHeader = "X-Impersonation-Mode"
+)
+
+// impersonate marks the response of a request made with an impersonation
+// token and records the request in the user's security log once it has been
+// handled.
+func impersonate(c *gin.Context, claims *utils.Claims) {
+	mode := "read-write"
+	if claims.ReadOnly {
+		mode = "read-only"
+	}
+
+	c.Set("impersonatorID", claims.ImpersonatorID)
+	c.Header(ImpersonatedByHeader, claims.ImpersonatorEmail)
+	c.Header(ImpersonationModeHeader, mode)
+
+	c.Next()
+
+	details := fmt.Sprintf("%s %s -> %d", c.Request.Method, c.Request.URL.Path, c.Writer.Status())
+	audit.RecordActor(c, models.EventImpersonatedRequest, claims.UserID, claims.ImpersonatorID, details)
+}
+
+// LimitImpersonation rejects writes made with a read-only impersonation
+// token. Must run after AuthMiddleware.
+func LimitImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := c.Get("claims")
+		if ok && claims.(*utils.Claims).ReadOnly && !isSafeMethod(c.Request.Method) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Impersonation token is read-only"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RejectImpersonatedWrites keeps every impersonation token, even a
+// read-write one, from changing credentials and other account settings.
+func RejectImpersonatedWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("impersonatorID"); ok && !isSafeMethod(c.Request.Method) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account settings cannot be changed while impersonating"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"expense-tracker/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limits requests per client for one route group. Clients are keyed
+// by the userID set by AuthMiddleware when present, otherwise by client IP.
+// name keeps the buckets of different groups apart. A disabled limit makes
+// this a no-op.
+func RateLimit(name string, limit ratelimit.Limit, store ratelimit.Store) gin.HandlerFunc {
+	if !limit.Enabled() {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		key := name + ":ip:" + c.ClientIP()
+		if userID, exists := c.Get("userID"); exists {
+			key = fmt.Sprintf("%s:user:%v", name, userID)
+		}
+
+		result, err := store.Take(key, limit)
+		if err != nil {
+			// Fail open: a broken limiter backend should not take the API down.
+			log.Printf("⚠️  Rate limiter error: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", limit.Policy())
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many requests, please slow down",
+				"retry_after": retryAfter,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
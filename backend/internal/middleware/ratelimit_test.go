@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func rateLimitedRouter(limit ratelimit.Limit, store ratelimit.Store, userID interface{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if userID != nil {
+		router.Use(func(c *gin.Context) {
+			c.Set("userID", userID)
+		})
+	}
+	router.Use(RateLimit("api", limit, store))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func get(router http.Handler, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	router := rateLimitedRouter(ratelimit.Limit{Requests: 2, Period: time.Minute}, ratelimit.NewMemoryStore(), nil)
+
+	w := get(router, "10.0.0.1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("first request: got %d", w.Code)
+	}
+	for header, want := range map[string]string{
+		"RateLimit-Policy":    "2;w=60",
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "1",
+		"RateLimit-Reset":     "30",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if w.Header().Get("Retry-After") != "" {
+		t.Error("allowed request should not carry Retry-After")
+	}
+
+	get(router, "10.0.0.1")
+	w = get(router, "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request: got %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+
+	if w := get(router, "10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("another IP was limited: got %d", w.Code)
+	}
+}
+
+func TestRateLimitKeysByUser(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 1, Period: time.Minute}
+
+	alice := rateLimitedRouter(limit, store, uint(1))
+	bob := rateLimitedRouter(limit, store, uint(2))
+
+	get(alice, "10.0.0.1")
+	if w := get(alice, "10.0.0.9"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("user switching IP was not limited: got %d", w.Code)
+	}
+	if w := get(bob, "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("other user on the same IP was limited: got %d", w.Code)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("backend down")
+}
+
+func TestRateLimitFailsOpenAndCanBeDisabled(t *testing.T) {
+	if w := get(rateLimitedRouter(ratelimit.Limit{Requests: 1, Period: time.Minute}, failingStore{}, nil), "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("broken store: got %d, want the request to pass", w.Code)
+	}
+
+	router := rateLimitedRouter(ratelimit.Limit{}, failingStore{}, nil)
+	for i := 0; i < 5; i++ {
+		w := get(router, "10.0.0.1")
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("disabled limit: got %d with headers %v", w.Code, w.Header())
+		}
+	}
+}
+
+func TestRateLimitResetCountsUp(t *testing.T) {
+	router := rateLimitedRouter(ratelimit.Limit{Requests: 4, Period: time.Minute}, ratelimit.NewMemoryStore(), nil)
+
+	previous := 0
+	for i := 0; i < 4; i++ {
+		reset, _ := strconv.Atoi(get(router, "10.0.0.1").Header().Get("RateLimit-Reset"))
+		if reset < previous {
+			t.Errorf("RateLimit-Reset went down from %d to %d", previous, reset)
+		}
+		previous = reset
+	}
+	if previous != 60 {
+		t.Errorf("empty bucket resets in %ds, want 60", previous)
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope lets personal access tokens through only when they were
+// granted scope. Requests authenticated with a login JWT have full access.
+// Must run after AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, isAPIToken := c.Get("apiTokenScopes")
+		if !isAPIToken {
+			c.Next()
+			return
+		}
+
+		for _, granted := range scopes.([]string) {
+			if granted == scope {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "API token is missing the " + scope + " scope"})
+		c.Abort()
+	}
+}
+
+// RejectAPITokens keeps personal access tokens away from account management
+// routes, which need an interactive login.
+func RejectAPITokens() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIToken := c.Get("apiTokenScopes"); isAPIToken {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot access this endpoint"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
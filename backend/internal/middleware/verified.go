@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail rejects users who have not verified their email yet.
+// It only does so when REQUIRE_EMAIL_VERIFICATION is "true"; otherwise it is
+// a no-op. Must run after AuthMiddleware.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	if os.Getenv("REQUIRE_EMAIL_VERIFICATION") != "true" {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		userID, _ := c.Get("userID")
+
+		var user models.User
+		if err := database.DB.Select("id", "email_verified_at").First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		if user.EmailVerifiedAt == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email address not verified"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkspaceHeader selects the workspace of a request, like the workspace_id
+// query parameter.
+const WorkspaceHeader = "X-Workspace-ID"
+
+// ResolveWorkspace picks the workspace a request works on from the
+// workspace_id query parameter or the X-Workspace-ID header, defaulting to
+// the user's personal workspace, and sets workspaceID and workspaceRole.
+// Users that are not members get a 404. Must run after AuthMiddleware.
+func ResolveWorkspace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("userID")
+
+		selector := c.Query("workspace_id")
+		if selector == "" {
+			selector = c.GetHeader(WorkspaceHeader)
+		}
+
+		query := database.DB.Joins("Workspace").Where("workspace_members.user_id = ?", userID)
+		if selector == "" {
+			query = query.Where(`"Workspace"."personal" = ?`, true)
+		} else {
+			workspaceID, err := strconv.ParseUint(selector, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+				c.Abort()
+				return
+			}
+			query = query.Where("workspace_members.workspace_id = ?", workspaceID)
+		}
+
+		var member models.WorkspaceMember
+		if err := query.First(&member).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+			c.Abort()
+			return
+		}
+
+		c.Set("workspaceID", member.WorkspaceID)
+		c.Set("workspaceRole", member.Role)
+
+		c.Next()
+	}
+}
+
+// RequireWorkspaceRole only lets members with at least role through. Must
+// run after ResolveWorkspace.
+func RequireWorkspaceRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !models.WorkspaceRoleAtLeast(c.GetString("workspaceRole"), role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Your workspace role does not allow this"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Scopes a personal access token can be granted.
+const (
+	ScopeCategoriesRead    = "categories:read"
+	ScopeCategoriesWrite   = "categories:write"
+	ScopeTransactionsRead  = "transactions:read"
+	ScopeTransactionsWrite = "transactions:write"
+	ScopeReportsRead       = "reports:read"
+)
+
+// APITokenPrefix starts every personal access token so AuthMiddleware can
+// tell them apart from JWTs (and secret scanners can spot leaked ones).
+const APITokenPrefix = "et_pat_"
+
+// APIToken is a long-lived personal access token for scripts. Only the
+// SHA-256 hash is stored; the token itself is shown once on creation.
+type APIToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name        string `gorm:"not null" json:"name"`
+	TokenHash   string `gorm:"uniqueIndex;not null" json:"-"`
+	TokenPrefix string `gorm:"not null" json:"token_prefix"`
+	// Scopes is a space-separated list, as in OAuth.
+	Scopes     string     `gorm:"not null" json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	UserID uint  `gorm:"index;not null" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+func (t *APIToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+type APITokenInput struct {
+	Name          string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=categories:read categories:write transactions:read transactions:write reports:read"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
+
+type APITokenResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (t *APIToken) ToResponse() APITokenResponse {
+	return APITokenResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		TokenPrefix: t.TokenPrefix,
+		Scopes:      t.ScopeList(),
+		ExpiresAt:   t.ExpiresAt,
+		LastUsedAt:  t.LastUsedAt,
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+// CreatedAPITokenResponse is returned once, when the token is created.
+type CreatedAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+}
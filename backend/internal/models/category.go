@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"expense-tracker/pkg/money"
+
 	"gorm.io/gorm"
 )
 
@@ -18,6 +20,9 @@ type Category struct {
 
 	// Foreign key - NULL means default/system category
 	UserID *uint `gorm:"index" json:"user_id,omitempty"`
+	// WorkspaceID is the ledger the category belongs to; NULL for system
+	// categories, which every workspace can use.
+	WorkspaceID *uint `gorm:"index" json:"workspace_id,omitempty"`
 
 	// Relations
 	User         *User         `gorm:"foreignKey:UserID" json:"-"`
@@ -38,29 +43,31 @@ type CategoryInput struct {
 
 // CategoryResponse is the response struct for category
 type CategoryResponse struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Type      string    `json:"type"`
-	Icon      string    `json:"icon"`
-	UserID    *uint     `json:"user_id,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Icon        string    `json:"icon"`
+	UserID      *uint     `json:"user_id,omitempty"`
+	WorkspaceID *uint     `json:"workspace_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // ToResponse converts Category to CategoryResponse
 func (c *Category) ToResponse() CategoryResponse {
 	return CategoryResponse{
-		ID:        c.ID,
-		Name:      c.Name,
-		Type:      c.Type,
-		Icon:      c.Icon,
-		UserID:    c.UserID,
-		CreatedAt: c.CreatedAt,
+		ID:          c.ID,
+		Name:        c.Name,
+		Type:        c.Type,
+		Icon:        c.Icon,
+		UserID:      c.UserID,
+		WorkspaceID: c.WorkspaceID,
+		CreatedAt:   c.CreatedAt,
 	}
 }
 
 // CategoryWithStats includes transaction statistics
 type CategoryWithStats struct {
 	CategoryResponse
-	TransactionCount int     `json:"transaction_count"`
-	TotalAmount      float64 `json:"total_amount"`
+	TransactionCount int          `json:"transaction_count"`
+	TotalAmount      money.Amount `json:"total_amount"`
 }
\ No newline at end of file
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// Purposes of an EmailVerificationToken.
+const (
+	EmailTokenVerify = "verify"
+	EmailTokenChange = "change"
+)
+
+// EmailVerificationToken is a single-use token proving that the user controls
+// Email. With the "change" purpose, using it also moves the account to Email.
+// Only the SHA-256 hash of the token is stored.
+type EmailVerificationToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	Email     string     `gorm:"not null" json:"email"`
+	Purpose   string     `gorm:"not null;default:'verify'" json:"purpose"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+
+	UserID uint  `gorm:"index;not null" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (EmailVerificationToken) TableName() string {
+	return "email_verification_tokens"
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
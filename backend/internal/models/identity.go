@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+// Identity links an account at an external OpenID Connect provider to a
+// user. A user can have several, at most one per provider account.
+type Identity struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Provider string `gorm:"uniqueIndex:idx_identity_provider_subject;not null" json:"provider"`
+	Subject  string `gorm:"uniqueIndex:idx_identity_provider_subject;not null" json:"-"`
+	// Email is the address the provider reported when the identity was last used.
+	Email      string     `json:"email"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	UserID uint  `gorm:"index;not null" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (Identity) TableName() string {
+	return "identities"
+}
+
+type IdentityResponse struct {
+	ID         uint       `json:"id"`
+	Provider   string     `json:"provider"`
+	Email      string     `json:"email"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (i *Identity) ToResponse() IdentityResponse {
+	return IdentityResponse{
+		ID:         i.ID,
+		Provider:   i.Provider,
+		Email:      i.Email,
+		LastUsedAt: i.LastUsedAt,
+		CreatedAt:  i.CreatedAt,
+	}
+}
+
+// OIDCState is a pending authorization request, looked up by the state
+// parameter when the provider redirects back. It holds the PKCE verifier and
+// nonce, and LinkUserID when a signed-in user is linking a new identity.
+type OIDCState struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	StateHash    string    `gorm:"uniqueIndex;not null" json:"-"`
+	Provider     string    `gorm:"not null" json:"provider"`
+	CodeVerifier string    `gorm:"not null" json:"-"`
+	Nonce        string    `gorm:"not null" json:"-"`
+	LinkUserID   *uint     `json:"link_user_id,omitempty"`
+	ExpiresAt    time.Time `gorm:"index;not null" json:"expires_at"`
+}
+
+func (OIDCState) TableName() string {
+	return "oidc_states"
+}
+
+type OIDCExchangeInput struct {
+	Code string `json:"code" binding:"required"`
+}
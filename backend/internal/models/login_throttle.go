@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// LoginThrottle counts recent failed login attempts for a key such as an
+// email address or a client IP, and how long that key is locked out.
+type LoginThrottle struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ThrottleKey   string     `gorm:"uniqueIndex;not null" json:"throttle_key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
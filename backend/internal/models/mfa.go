@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// RecoveryCode is a one-time code that can replace a TOTP code when the user
+// has lost their authenticator. Only the SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	CodeHash string     `gorm:"index;not null" json:"-"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+
+	UserID uint  `gorm:"index;not null" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+type TOTPSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TOTPCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTOTPInput struct {
+	ReauthInput
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallengeResponse is returned by Login instead of LoginResponse when the
+// user has two-factor authentication enabled.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// MFAVerifyInput completes a two-factor login. Code is either a TOTP code or
+// an unused recovery code.
+type MFAVerifyInput struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// ProofOfWorkRedemption records a solved proof-of-work challenge so it
+// cannot be used twice. Rows can be purged once ExpiresAt has passed.
+type ProofOfWorkRedemption struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ChallengeID string    `gorm:"uniqueIndex;not null" json:"challenge_id"`
+	ExpiresAt   time.Time `gorm:"index;not null" json:"expires_at"`
+}
+
+func (ProofOfWorkRedemption) TableName() string {
+	return "pow_redemptions"
+}
+
+// ProofOfWorkInput is embedded in the inputs of endpoints that may demand a
+// proof of work.
+type ProofOfWorkInput struct {
+	PoWChallenge string `json:"pow_challenge"`
+	PoWNonce     string `json:"pow_nonce"`
+}
+
+type ProofOfWorkChallengeResponse struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	Algorithm  string    `json:"algorithm"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken is a persisted, single-use refresh token. Tokens issued from
+// the same login share a FamilyID so the whole chain can be revoked when an
+// already-used token is presented again.
+type RefreshToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	FamilyID  string     `gorm:"index;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	UserID    uint     `gorm:"index;not null" json:"user_id"`
+	SessionID *uint    `gorm:"index" json:"session_id,omitempty"`
+	User      *User    `gorm:"foreignKey:UserID" json:"-"`
+	Session   *Session `gorm:"foreignKey:SessionID" json:"-"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// RefreshInput may be empty when the refresh token is sent as a cookie.
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// RevokedToken records the jti of an access token that was logged out before
+// it expired. Rows can be purged once ExpiresAt has passed.
+type RevokedToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	JTI       string    `gorm:"uniqueIndex;not null" json:"jti"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+}
+
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+type LogoutInput struct {
+	RefreshToken string `json:"refresh_token"`
+	All          bool   `json:"all"`
+}
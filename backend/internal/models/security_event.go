@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+)
+
+// Security event types recorded by the audit package.
+const (
+	EventRegister               = "register"
+	EventLoginSucceeded         = "login_succeeded"
+	EventLoginFailed            = "login_failed"
+	EventMFAFailed              = "mfa_failed"
+	EventMagicLinkRequested     = "magic_link_requested"
+	EventIdentityLinked         = "identity_linked"
+	EventIdentityUnlinked       = "identity_unlinked"
+	EventPasskeyAdded           = "passkey_added"
+	EventPasskeyRemoved         = "passkey_removed"
+	EventLogout                 = "logout"
+	EventLogoutAll              = "logout_all"
+	EventRefreshTokenReuse      = "refresh_token_reuse"
+	EventSessionRevoked         = "session_revoked"
+	EventPasswordChanged        = "password_changed"
+	EventPasswordResetRequested = "password_reset_requested"
+	EventPasswordReset          = "password_reset"
+	EventEmailVerified          = "email_verified"
+	EventEmailChangeRequested   = "email_change_requested"
+	EventEmailChanged           = "email_changed"
+	EventMFAEnabled             = "mfa_enabled"
+	EventMFADisabled            = "mfa_disabled"
+	EventRecoveryCodesReset     = "recovery_codes_regenerated"
+	EventAPITokenCreated        = "api_token_created"
+	EventAPITokenRevoked        = "api_token_revoked"
+	EventDataExported           = "data_exported"
+	EventDeletionScheduled      = "account_deletion_scheduled"
+	EventDeletionCancelled      = "account_deletion_cancelled"
+	EventAdminUserDisabled      = "admin_user_disabled"
+	EventAdminUserEnabled       = "admin_user_enabled"
+	EventAdminCategoryCreated   = "admin_category_created"
+	EventAdminCategoryUpdated   = "admin_category_updated"
+	EventAdminCategoryDeleted   = "admin_category_deleted"
+	EventImpersonationStarted   = "impersonation_started"
+	EventImpersonationEnded     = "impersonation_ended"
+	EventImpersonatedRequest    = "impersonated_request"
+	EventWorkspaceMemberAdded   = "workspace_member_added"
+	EventWorkspaceMemberRemoved = "workspace_member_removed"
+	EventWorkspaceRoleChanged   = "workspace_member_role_changed"
+	EventWorkspaceDeleted       = "workspace_deleted"
+)
+
+// SecurityEvent is an append-only audit record. Rows are never updated; they
+// are only removed when the account they belong to is purged.
+type SecurityEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	Type      string `gorm:"index;not null" json:"type"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	Details   string `json:"details,omitempty"`
+
+	// UserID is the account the event is about. It is nil for failed logins
+	// with an unknown email.
+	UserID *uint `gorm:"index" json:"user_id,omitempty"`
+	// ActorID is set when an admin acted on someone else's account.
+	ActorID *uint `json:"actor_id,omitempty"`
+}
+
+func (SecurityEvent) TableName() string {
+	return "security_events"
+}
+
+type SecurityEventResponse struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Details   string    `json:"details,omitempty"`
+	UserID    *uint     `json:"user_id,omitempty"`
+	ActorID   *uint     `json:"actor_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (e *SecurityEvent) ToResponse() SecurityEventResponse {
+	return SecurityEventResponse{
+		ID:        e.ID,
+		Type:      e.Type,
+		IP:        e.IP,
+		UserAgent: e.UserAgent,
+		Details:   e.Details,
+		UserID:    e.UserID,
+		ActorID:   e.ActorID,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+// SecurityEventFilter holds the query parameters of the security event
+// listings. UserID, IP and Type filters are only honoured for admins.
+type SecurityEventFilter struct {
+	UserID    *uint      `form:"user_id"`
+	Type      string     `form:"type"`
+	IP        string     `form:"ip"`
+	StartDate *time.Time `form:"start_date"`
+	EndDate   *time.Time `form:"end_date"`
+	Page      int        `form:"page,default=1"`
+	Limit     int        `form:"limit,default=20"`
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"time"
+
+	"expense-tracker/pkg/money"
+)
+
+// Split methods for TransactionSplitInput.
+const (
+	SplitEqual      = "equal"
+	SplitExact      = "exact"
+	SplitPercentage = "percentage"
+)
+
+// Contact is a named person without an account who can take part in split
+// expenses of a workspace.
+type Contact struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	WorkspaceID uint   `gorm:"uniqueIndex:idx_workspace_contact;not null" json:"workspace_id"`
+	Name        string `gorm:"uniqueIndex:idx_workspace_contact;not null" json:"name"`
+}
+
+func (Contact) TableName() string {
+	return "contacts"
+}
+
+type ContactInput struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+type ContactResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (c *Contact) ToResponse() ContactResponse {
+	return ContactResponse{
+		ID:        c.ID,
+		Name:      c.Name,
+		CreatedAt: c.CreatedAt,
+	}
+}
+
+// TransactionSplit is one participant's share of a split expense. The
+// participant is a user (UserID) or a contact (ContactID); the payer is the
+// transaction's PayerUserID. Splits outlive purged participants and payers so
+// the other members' balances stay correct.
+type TransactionSplit struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	TransactionID uint         `gorm:"index;not null" json:"transaction_id"`
+	WorkspaceID   uint         `gorm:"index;not null" json:"workspace_id"`
+	UserID        *uint        `gorm:"index" json:"user_id,omitempty"`
+	ContactID     *uint        `gorm:"index" json:"contact_id,omitempty"`
+	Amount        money.Amount `gorm:"type:bigint;not null" json:"amount"`
+
+	User    *User    `gorm:"foreignKey:UserID;constraint:-" json:"-"`
+	Contact *Contact `gorm:"foreignKey:ContactID" json:"-"`
+}
+
+func (TransactionSplit) TableName() string {
+	return "transaction_splits"
+}
+
+// Settlement records a payment that evens out split balances. It is linked
+// to the transaction it was booked as; deleting that transaction undoes the
+// settlement.
+type Settlement struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	WorkspaceID   uint         `gorm:"index;not null" json:"workspace_id"`
+	FromUserID    *uint        `json:"from_user_id,omitempty"`
+	FromContactID *uint        `json:"from_contact_id,omitempty"`
+	ToUserID      *uint        `json:"to_user_id,omitempty"`
+	ToContactID   *uint        `json:"to_contact_id,omitempty"`
+	Amount        money.Amount `gorm:"type:bigint;not null;check:amount > 0" json:"amount"`
+	TransactionID uint         `gorm:"uniqueIndex;not null" json:"transaction_id"`
+
+	Transaction *Transaction `gorm:"foreignKey:TransactionID" json:"-"`
+	FromUser    *User        `gorm:"foreignKey:FromUserID;constraint:-" json:"-"`
+	FromContact *Contact     `gorm:"foreignKey:FromContactID" json:"-"`
+	ToUser      *User        `gorm:"foreignKey:ToUserID;constraint:-" json:"-"`
+	ToContact   *Contact     `gorm:"foreignKey:ToContactID" json:"-"`
+}
+
+func (Settlement) TableName() string {
+	return "settlements"
+}
+
+// ParticipantInput names a registered user or a contact of the workspace.
+type ParticipantInput struct {
+	UserID    *uint `json:"user_id"`
+	ContactID *uint `json:"contact_id"`
+}
+
+type SplitParticipantInput struct {
+	ParticipantInput
+	// Amount is used by the exact method, Percentage by the percentage one.
+	Amount     money.Amount `json:"amount"`
+	Percentage float64      `json:"percentage"`
+}
+
+type TransactionSplitInput struct {
+	Method       string                  `json:"method" binding:"required,oneof=equal exact percentage"`
+	Participants []SplitParticipantInput `json:"participants" binding:"required,min=1,dive"`
+}
+
+// SettlementInput records a payment between two participants. One of them
+// must be the current user: the settlement is booked as their income (when
+// receiving) or expense (when paying) in CategoryID.
+type SettlementInput struct {
+	From       ParticipantInput `json:"from" binding:"required"`
+	To         ParticipantInput `json:"to" binding:"required"`
+	Amount     money.Amount     `json:"amount" binding:"required,gt=0"`
+	Date       time.Time        `json:"date" binding:"required"`
+	CategoryID uint             `json:"category_id" binding:"required"`
+}
+
+type ParticipantResponse struct {
+	UserID    *uint  `json:"user_id,omitempty"`
+	ContactID *uint  `json:"contact_id,omitempty"`
+	Name      string `json:"name"`
+}
+
+// NewParticipantResponse describes a user or contact. Users that were
+// purged since are shown as a former member.
+func NewParticipantResponse(userID *uint, user *User, contactID *uint, contact *Contact) ParticipantResponse {
+	response := ParticipantResponse{UserID: userID, ContactID: contactID, Name: "Former member"}
+	switch {
+	case user != nil:
+		response.Name = user.Name
+	case contact != nil:
+		response.Name = contact.Name
+	}
+	return response
+}
+
+type TransactionSplitResponse struct {
+	Participant ParticipantResponse `json:"participant"`
+	Amount      money.Amount        `json:"amount"`
+}
+
+func (s *TransactionSplit) ToResponse() TransactionSplitResponse {
+	return TransactionSplitResponse{
+		Participant: NewParticipantResponse(s.UserID, s.User, s.ContactID, s.Contact),
+		Amount:      s.Amount,
+	}
+}
+
+type SettlementResponse struct {
+	ID            uint                `json:"id"`
+	From          ParticipantResponse `json:"from"`
+	To            ParticipantResponse `json:"to"`
+	Amount        money.Amount        `json:"amount"`
+	TransactionID uint                `json:"transaction_id"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+func (s *Settlement) ToResponse() SettlementResponse {
+	return SettlementResponse{
+		ID:            s.ID,
+		From:          NewParticipantResponse(s.FromUserID, s.FromUser, s.FromContactID, s.FromContact),
+		To:            NewParticipantResponse(s.ToUserID, s.ToUser, s.ToContactID, s.ToContact),
+		Amount:        s.Amount,
+		TransactionID: s.TransactionID,
+		CreatedAt:     s.CreatedAt,
+	}
+}
+
+// Balance is what a participant is owed (positive) or owes (negative).
+type Balance struct {
+	Participant ParticipantResponse `json:"participant"`
+	Amount      money.Amount        `json:"amount"`
+}
+
+// SettleUpPayment is a suggested payment that evens out balances.
+type SettleUpPayment struct {
+	From   ParticipantResponse `json:"from"`
+	To     ParticipantResponse `json:"to"`
+	Amount money.Amount        `json:"amount"`
+}
+
+type BalancesResponse struct {
+	Balances []Balance         `json:"balances"`
+	SettleUp []SettleUpPayment `json:"settle_up"`
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"expense-tracker/pkg/money"
+
 	"gorm.io/gorm"
 )
 
@@ -12,38 +14,63 @@ type Transaction struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	Amount      float64   `gorm:"not null;check:amount > 0" json:"amount" binding:"required,gt=0"`
-	Description string    `gorm:"not null" json:"description" binding:"required,min=1,max=255"`
-	Date        time.Time `gorm:"not null;index" json:"date" binding:"required"`
-	Type        string    `gorm:"not null;check:type IN ('income', 'expense')" json:"type" binding:"required,oneof=income expense"`
+	// Amount is in minor units (cents).
+	Amount      money.Amount `gorm:"type:bigint;not null;check:amount > 0" json:"amount" binding:"required,gt=0"`
+	Description string       `gorm:"not null" json:"description" binding:"required,min=1,max=255"`
+	Date        time.Time    `gorm:"not null;index" json:"date" binding:"required"`
+	Type        string       `gorm:"not null;check:type IN ('income', 'expense')" json:"type" binding:"required,oneof=income expense"`
+
+	// UserID is the member who recorded the transaction, WorkspaceID the
+	// ledger it belongs to. Rows from before workspaces existed have
+	// WorkspaceID 0 until jobs.MigratePersonalWorkspaces moves them.
+	UserID      uint `gorm:"index;not null" json:"user_id"`
+	WorkspaceID uint `gorm:"index;not null;default:0" json:"workspace_id"`
+	CategoryID  uint `gorm:"index;not null" json:"category_id" binding:"required"`
 
-	UserID     uint `gorm:"index;not null" json:"user_id"`
-	CategoryID uint `gorm:"index;not null" json:"category_id" binding:"required"`
+	// PayerUserID is the member who paid, set from UserID on creation. Unlike
+	// UserID it is not handed over when the account is purged, so split
+	// balances keep crediting the right person.
+	PayerUserID uint `gorm:"index;not null;default:0" json:"-"`
 
-	User     *User     `gorm:"foreignKey:UserID" json:"-"`
-	Category *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	User     *User              `gorm:"foreignKey:UserID" json:"-"`
+	Category *Category          `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Splits   []TransactionSplit `gorm:"foreignKey:TransactionID" json:"splits,omitempty"`
 }
 
 func (Transaction) TableName() string {
 	return "transactions"
 }
 
+// BeforeCreate records the member creating the transaction as its payer.
+func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
+	if t.PayerUserID == 0 {
+		t.PayerUserID = t.UserID
+	}
+	return nil
+}
+
 type TransactionInput struct {
-	Amount      float64   `json:"amount" binding:"required,gt=0"`
-	Description string    `json:"description" binding:"required,min=1,max=255"`
-	Date        time.Time `json:"date" binding:"required"`
-	Type        string    `json:"type" binding:"required,oneof=income expense"`
-	CategoryID  uint      `json:"category_id" binding:"required"`
+	Amount      money.Amount `json:"amount" binding:"required,gt=0"`
+	Description string       `json:"description" binding:"required,min=1,max=255"`
+	Date        time.Time    `json:"date" binding:"required"`
+	Type        string       `json:"type" binding:"required,oneof=income expense"`
+	CategoryID  uint         `json:"category_id" binding:"required"`
+	// Split shares an expense among participants; the payer is the user
+	// recording it.
+	Split *TransactionSplitInput `json:"split"`
 }
 
 type TransactionResponse struct {
-	ID          uint             `json:"id"`
-	Amount      float64          `json:"amount"`
-	Description string           `json:"description"`
-	Date        time.Time        `json:"date"`
-	Type        string           `json:"type"`
-	Category    CategoryResponse `json:"category"`
-	CreatedAt   time.Time        `json:"created_at"`
+	ID          uint                       `json:"id"`
+	Amount      money.Amount               `json:"amount"`
+	Description string                     `json:"description"`
+	Date        time.Time                  `json:"date"`
+	Type        string                     `json:"type"`
+	Category    CategoryResponse           `json:"category"`
+	UserID      uint                       `json:"user_id"`
+	WorkspaceID uint                       `json:"workspace_id"`
+	Splits      []TransactionSplitResponse `json:"splits,omitempty"`
+	CreatedAt   time.Time                  `json:"created_at"`
 }
 
 func (t *Transaction) ToResponse() TransactionResponse {
@@ -52,6 +79,11 @@ func (t *Transaction) ToResponse() TransactionResponse {
 		categoryResp = t.Category.ToResponse()
 	}
 
+	var splits []TransactionSplitResponse
+	for _, split := range t.Splits {
+		splits = append(splits, split.ToResponse())
+	}
+
 	return TransactionResponse{
 		ID:          t.ID,
 		Amount:      t.Amount,
@@ -59,6 +91,9 @@ func (t *Transaction) ToResponse() TransactionResponse {
 		Date:        t.Date,
 		Type:        t.Type,
 		Category:    categoryResp,
+		UserID:      t.UserID,
+		WorkspaceID: t.WorkspaceID,
+		Splits:      splits,
 		CreatedAt:   t.CreatedAt,
 	}
 }
@@ -73,26 +108,26 @@ type TransactionFilter struct {
 }
 
 type MonthlyReport struct {
-	Month            string  `json:"month"`
-	TotalIncome      float64 `json:"total_income"`
-	TotalExpense     float64 `json:"total_expense"`
-	Balance          float64 `json:"balance"`
-	TransactionCount int     `json:"transaction_count"`
+	Month            string       `json:"month"`
+	TotalIncome      money.Amount `json:"total_income"`
+	TotalExpense     money.Amount `json:"total_expense"`
+	Balance          money.Amount `json:"balance"`
+	TransactionCount int          `json:"transaction_count"`
 }
 
 type CategorySummary struct {
-	CategoryID   uint    `json:"category_id"`
-	CategoryName string  `json:"category_name"`
-	CategoryIcon string  `json:"category_icon"`
-	TotalAmount  float64 `json:"total_amount"`
-	Count        int     `json:"count"`
-	Percentage   float64 `json:"percentage"`
+	CategoryID   uint         `json:"category_id"`
+	CategoryName string       `json:"category_name"`
+	CategoryIcon string       `json:"category_icon"`
+	TotalAmount  money.Amount `json:"total_amount"`
+	Count        int          `json:"count"`
+	Percentage   float64      `json:"percentage"`
 }
 
 type DashboardStats struct {
-	TotalIncome        float64               `json:"total_income"`
-	TotalExpense       float64               `json:"total_expense"`
-	Balance            float64               `json:"balance"`
+	TotalIncome        money.Amount          `json:"total_income"`
+	TotalExpense       money.Amount          `json:"total_expense"`
+	Balance            money.Amount          `json:"balance"`
 	TransactionCount   int                   `json:"transaction_count"`
 	CategoryBreakdown  []CategorySummary     `json:"category_breakdown"`
 	RecentTransactions []TransactionResponse `json:"recent_transactions"`
@@ -6,6 +6,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles. Every account is a RoleUser unless promoted with cmd/setrole.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
 	CreatedAt time.Time      `json:"created_at"`
@@ -16,6 +22,29 @@ type User struct {
 	Email    string `gorm:"uniqueIndex;not null" json:"email" binding:"required,email"`
 	Password string `gorm:"not null" json:"-" binding:"required,min=6"`
 
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	Role string `gorm:"not null;default:'user';index" json:"role"`
+	// Disabled accounts cannot log in and all their tokens are revoked.
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+
+	// TOTP two-factor authentication. TOTPSecret is set during enrolment and
+	// only enforced once TOTPEnabledAt is set. TOTPLastStep blocks code replay.
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabledAt *time.Time `json:"-"`
+	TOTPLastStep  int64      `json:"-"`
+
+	// WebAuthnHandle is the random, opaque user handle stored in the user's
+	// passkeys. It is created with the first passkey.
+	WebAuthnHandle []byte `gorm:"uniqueIndex" json:"-"`
+
+	// Tokens issued at or before this time are rejected ("log out everywhere").
+	TokensValidAfter *time.Time `json:"-"`
+
+	// When set, the account and everything it owns is permanently deleted at
+	// this time unless the user logs in again before then.
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletion_scheduled_at,omitempty"`
+
 	Categories   []Category    `gorm:"foreignKey:UserID" json:"categories,omitempty"`
 	Transactions []Transaction `gorm:"foreignKey:UserID" json:"transactions,omitempty"`
 }
@@ -24,34 +53,133 @@ func (User) TableName() string {
 	return "users"
 }
 
+// AfterCreate gives every new account its personal workspace.
+func (u *User) AfterCreate(tx *gorm.DB) error {
+	_, err := CreatePersonalWorkspace(tx, u.ID)
+	return err
+}
+
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              uint       `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	MFAEnabled      bool       `json:"mfa_enabled"`
+	CreatedAt       time.Time  `json:"created_at"`
+
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
 }
 
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Name:      u.Name,
-		Email:     u.Email,
-		CreatedAt: u.CreatedAt,
+		ID:              u.ID,
+		Name:            u.Name,
+		Email:           u.Email,
+		Role:            u.Role,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		MFAEnabled:      u.TOTPEnabledAt != nil,
+		CreatedAt:       u.CreatedAt,
+
+		DeletionScheduledAt: u.DeletionScheduledAt,
 	}
 }
 
+// RegisterInput only bounds the password length; the rest of the password
+// policy is enforced by utils.ValidatePassword.
 type RegisterInput struct {
 	Name     string `json:"name" binding:"required,min=2,max=100"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required,max=128"`
+	ProofOfWorkInput
 }
 
 type LoginInput struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	ProofOfWorkInput
 }
 
+type MagicLinkInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type MagicLinkVerifyInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// LoginResponse carries the tokens in the body, or only the CSRF token when
+// AUTH_COOKIES is enabled and the tokens are set as HttpOnly cookies.
 type LoginResponse struct {
-	User  UserResponse `json:"user"`
-	Token string       `json:"token"`
-}
\ No newline at end of file
+	User         UserResponse `json:"user"`
+	Token        string       `json:"token,omitempty"`
+	RefreshToken string       `json:"refresh_token,omitempty"`
+	CSRFToken    string       `json:"csrf_token,omitempty"`
+	ExpiresIn    int          `json:"expires_in"`
+}
+
+type UpdateProfileInput struct {
+	Name string `json:"name" binding:"required,min=2,max=100"`
+}
+
+// ReauthInput confirms a sensitive change: the password, or for accounts
+// without one a TOTP code (or nothing right after signing in).
+type ReauthInput struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// ChangePasswordInput also sets a first password on accounts created through
+// an identity provider, which confirm with Code instead of CurrentPassword.
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+	NewPassword     string `json:"new_password" binding:"required,max=128"`
+}
+
+type ChangeEmailInput struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	ReauthInput
+}
+
+type DeleteAccountInput struct {
+	ReauthInput
+}
+
+// AdminUserFilter holds the query parameters of the admin user listing.
+type AdminUserFilter struct {
+	Query    string `form:"q"`
+	Role     string `form:"role" binding:"omitempty,oneof=user admin"`
+	Disabled *bool  `form:"disabled"`
+	Page     int    `form:"page,default=1"`
+	Limit    int    `form:"limit,default=20"`
+}
+
+// AdminUserResponse is the admin view of an account.
+type AdminUserResponse struct {
+	UserResponse
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ImpersonateInput is the body of the admin impersonation endpoint. The
+// reason is kept in the security log.
+type ImpersonateInput struct {
+	Reason      string `json:"reason" binding:"required,max=500"`
+	AllowWrites bool   `json:"allow_writes"`
+}
+
+type ImpersonationResponse struct {
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	ReadOnly  bool         `json:"read_only"`
+	User      UserResponse `json:"user"`
+}
+
+func (u *User) ToAdminResponse() AdminUserResponse {
+	return AdminUserResponse{
+		UserResponse: u.ToResponse(),
+		DisabledAt:   u.DisabledAt,
+		UpdatedAt:    u.UpdatedAt,
+	}
+}
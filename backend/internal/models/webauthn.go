@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebAuthnCredential is a passkey registered by a user.
+type WebAuthnCredential struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name            string `gorm:"not null" json:"name"`
+	CredentialID    []byte `gorm:"uniqueIndex;not null" json:"-"`
+	PublicKey       []byte `gorm:"not null" json:"-"`
+	AttestationType string `json:"-"`
+	// Transports is a space-separated list such as "internal hybrid".
+	Transports string `json:"-"`
+	AAGUID     []byte `json:"-"`
+	// SignCount is the authenticator's signature counter at the last login.
+	// A counter that does not increase points to a cloned authenticator.
+	SignCount uint32 `gorm:"not null;default:0" json:"-"`
+	// Flags holds the raw authenticator data flags from registration.
+	Flags      uint8      `gorm:"not null;default:0" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	UserID uint  `gorm:"index;not null" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+type WebAuthnCredentialResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (c *WebAuthnCredential) ToResponse() WebAuthnCredentialResponse {
+	return WebAuthnCredentialResponse{
+		ID:         c.ID,
+		Name:       c.Name,
+		LastUsedAt: c.LastUsedAt,
+		CreatedAt:  c.CreatedAt,
+	}
+}
+
+// WebAuthnSession holds the server side of a registration or login
+// ceremony between its begin and finish requests.
+type WebAuthnSession struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	TokenHash string `gorm:"uniqueIndex;not null" json:"-"`
+	// Purpose is "register" or "login".
+	Purpose string `gorm:"not null" json:"purpose"`
+	// Data is the JSON-encoded webauthn.SessionData.
+	Data      string    `gorm:"type:text;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+
+	// UserID is set for registration ceremonies.
+	UserID *uint `gorm:"index" json:"user_id,omitempty"`
+}
+
+func (WebAuthnSession) TableName() string {
+	return "webauthn_sessions"
+}
+
+// WebAuthn ceremony purposes.
+const (
+	WebAuthnRegister = "register"
+	WebAuthnLogin    = "login"
+)
+
+// WebAuthnFinishInput carries the browser's PublicKeyCredential as JSON.
+type WebAuthnFinishInput struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Name       string          `json:"name" binding:"omitempty,max=100"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// WebAuthnBeginResponse returns the options to pass to
+// navigator.credentials.create/get and the ceremony to finish.
+type WebAuthnBeginResponse struct {
+	SessionID string      `json:"session_id"`
+	Options   interface{} `json:"options"`
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Workspace roles. Owners manage the workspace and its members, editors
+// change categories and transactions, viewers only read them.
+const (
+	WorkspaceOwner  = "owner"
+	WorkspaceEditor = "editor"
+	WorkspaceViewer = "viewer"
+)
+
+var workspaceRoleRank = map[string]int{
+	WorkspaceViewer: 1,
+	WorkspaceEditor: 2,
+	WorkspaceOwner:  3,
+}
+
+// WorkspaceRoleAtLeast reports whether role grants at least the rights of min.
+func WorkspaceRoleAtLeast(role, min string) bool {
+	return workspaceRoleRank[role] >= workspaceRoleRank[min]
+}
+
+// Workspace is a ledger owning categories and transactions, shared by its
+// members. Every user has one Personal workspace that cannot be shared or
+// deleted.
+type Workspace struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name     string `gorm:"not null" json:"name"`
+	Personal bool   `gorm:"not null;default:false" json:"personal"`
+
+	Members []WorkspaceMember `gorm:"foreignKey:WorkspaceID" json:"members,omitempty"`
+}
+
+func (Workspace) TableName() string {
+	return "workspaces"
+}
+
+type WorkspaceMember struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	WorkspaceID uint   `gorm:"uniqueIndex:idx_workspace_member;not null" json:"workspace_id"`
+	UserID      uint   `gorm:"uniqueIndex:idx_workspace_member;index;not null" json:"user_id"`
+	Role        string `gorm:"not null;check:role IN ('owner', 'editor', 'viewer')" json:"role"`
+
+	Workspace *Workspace `gorm:"foreignKey:WorkspaceID" json:"-"`
+	User      *User      `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (WorkspaceMember) TableName() string {
+	return "workspace_members"
+}
+
+// CreatePersonalWorkspace creates the personal workspace of a user.
+func CreatePersonalWorkspace(tx *gorm.DB, userID uint) (*Workspace, error) {
+	workspace := Workspace{Name: "Personal", Personal: true}
+	if err := tx.Create(&workspace).Error; err != nil {
+		return nil, err
+	}
+
+	member := WorkspaceMember{WorkspaceID: workspace.ID, UserID: userID, Role: WorkspaceOwner}
+	if err := tx.Create(&member).Error; err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+type WorkspaceInput struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+type WorkspaceMemberInput struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=owner editor viewer"`
+}
+
+type WorkspaceMemberRoleInput struct {
+	Role string `json:"role" binding:"required,oneof=owner editor viewer"`
+}
+
+// WorkspaceResponse describes a workspace from the point of view of one
+// member, whose role it includes.
+type WorkspaceResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Personal  bool      `json:"personal"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (m *WorkspaceMember) ToWorkspaceResponse() WorkspaceResponse {
+	response := WorkspaceResponse{ID: m.WorkspaceID, Role: m.Role}
+	if m.Workspace != nil {
+		response.Name = m.Workspace.Name
+		response.Personal = m.Workspace.Personal
+		response.CreatedAt = m.Workspace.CreatedAt
+	}
+	return response
+}
+
+type WorkspaceMemberResponse struct {
+	UserID   uint      `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+func (m *WorkspaceMember) ToResponse() WorkspaceMemberResponse {
+	response := WorkspaceMemberResponse{UserID: m.UserID, Role: m.Role, JoinedAt: m.CreatedAt}
+	if m.User != nil {
+		response.Name = m.User.Name
+		response.Email = m.User.Email
+	}
+	return response
+}
+
+type WorkspaceDetailResponse struct {
+	WorkspaceResponse
+	Members []WorkspaceMemberResponse `json:"members"`
+}
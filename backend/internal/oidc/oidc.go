@@ -0,0 +1,220 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var ErrUnknownProvider = errors.New("unknown OIDC provider")
+
+// Provider is an OpenID Connect identity provider configured from the
+// environment. Discovery happens on first use, so the server starts even when
+// a provider is unreachable.
+type Provider struct {
+	Name string
+	// TrustEmail treats the email of every identity as verified, for company
+	// IdPs that do not send the email_verified claim.
+	TrustEmail bool
+
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+// Identity is what the provider asserts about the user who signed in.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+var providers = map[string]*Provider{}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// LoadProviders reads the providers listed in OIDC_PROVIDERS (comma-separated
+// names). Each name NAME is configured by OIDC_NAME_ISSUER, OIDC_NAME_CLIENT_ID,
+// OIDC_NAME_CLIENT_SECRET and optionally OIDC_NAME_SCOPES and
+// OIDC_NAME_TRUST_EMAIL.
+func LoadProviders() {
+	providers = map[string]*Provider{}
+
+	baseURL := strings.TrimSuffix(os.Getenv("OIDC_REDIRECT_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080/api/auth/oidc"
+	}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		p := &Provider{
+			Name:         name,
+			TrustEmail:   os.Getenv(prefix+"TRUST_EMAIL") == "true",
+			issuer:       os.Getenv(prefix + "ISSUER"),
+			clientID:     os.Getenv(prefix + "CLIENT_ID"),
+			clientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			redirectURL:  baseURL + "/" + name + "/callback",
+			scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if len(p.scopes) == 0 {
+			p.scopes = []string{gooidc.ScopeOpenID, "email", "profile"}
+		}
+
+		if p.issuer == "" || p.clientID == "" {
+			log.Printf("⚠️  OIDC provider %q is missing %sISSUER or %sCLIENT_ID, skipping", name, prefix, prefix)
+			continue
+		}
+
+		providers[name] = p
+		log.Printf("🔑 OIDC provider %q configured (%s)", name, p.issuer)
+	}
+}
+
+// Get returns the provider called name.
+func Get(name string) (*Provider, error) {
+	p, ok := providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// Names lists the configured providers.
+func Names() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func withClient(ctx context.Context) context.Context {
+	ctx = gooidc.ClientContext(ctx, httpClient)
+	return context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+}
+
+// discover fetches the provider metadata once it is first needed. Failures
+// are not cached so a later request can retry.
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth != nil {
+		return nil
+	}
+
+	provider, err := gooidc.NewProvider(withClient(ctx), p.issuer)
+	if err != nil {
+		return fmt.Errorf("discover %s: %w", p.issuer, err)
+	}
+
+	p.verifier = provider.Verifier(&gooidc.Config{ClientID: p.clientID})
+	p.oauth = &oauth2.Config{
+		ClientID:     p.clientID,
+		ClientSecret: p.clientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.redirectURL,
+		Scopes:       p.scopes,
+	}
+	return nil
+}
+
+// AuthCodeURL returns the provider's authorization URL for the
+// authorization-code flow with a PKCE S256 challenge derived from verifier.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+
+	return p.oauth.AuthCodeURL(state,
+		gooidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier),
+	), nil
+}
+
+// Exchange redeems an authorization code and verifies the returned ID token,
+// including its nonce.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx = withClient(ctx)
+
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string       `json:"email"`
+		EmailVerified flexibleBool `json:"email_verified"`
+		Name          string       `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("parse id_token claims: %w", err)
+	}
+
+	return &Identity{
+		Subject:       idToken.Subject,
+		Email:         strings.TrimSpace(claims.Email),
+		EmailVerified: bool(claims.EmailVerified) || p.TrustEmail,
+		Name:          claims.Name,
+	}, nil
+}
+
+// flexibleBool accepts both true and "true"; some providers send
+// email_verified as a string.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case bool:
+		*b = flexibleBool(v)
+	case string:
+		*b = flexibleBool(v == "true")
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package passkey
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/models"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// CeremonyTTL is how long a registration or login ceremony may take.
+const CeremonyTTL = 5 * time.Minute
+
+// Default is the relying party used by the handlers. main replaces it with
+// the result of NewFromEnv; tests can swap in their own, e.g. one whose
+// origin matches a software authenticator.
+var Default *webauthn.WebAuthn
+
+// NewFromEnv configures the relying party from WEBAUTHN_RP_ID,
+// WEBAUTHN_RP_NAME and WEBAUTHN_RP_ORIGINS (comma-separated, defaulting to
+// FRONTEND_URL).
+func NewFromEnv() (*webauthn.WebAuthn, error) {
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		rpID = "localhost"
+	}
+
+	rpName := os.Getenv("WEBAUTHN_RP_NAME")
+	if rpName == "" {
+		rpName = "Expense Tracker"
+	}
+
+	origins := os.Getenv("WEBAUTHN_RP_ORIGINS")
+	if origins == "" {
+		origins = os.Getenv("FRONTEND_URL")
+	}
+	if origins == "" {
+		origins = "http://localhost:3000"
+	}
+
+	return New(rpID, rpName, strings.Split(origins, ","))
+}
+
+// New configures a relying party that requires user verification, so a
+// passkey login counts as both factors.
+func New(rpID, rpName string, origins []string) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: rpName,
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: CeremonyTTL},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: CeremonyTTL},
+		},
+	})
+}
+
+// User adapts a models.User and its stored credentials to webauthn.User.
+type User struct {
+	*models.User
+	Credentials []models.WebAuthnCredential
+}
+
+func (u *User) WebAuthnID() []byte {
+	return u.WebAuthnHandle
+}
+
+func (u *User) WebAuthnName() string {
+	return u.Email
+}
+
+func (u *User) WebAuthnDisplayName() string {
+	return u.Name
+}
+
+func (u *User) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.Credentials))
+	for _, c := range u.Credentials {
+		credentials = append(credentials, ToCredential(&c))
+	}
+	return credentials
+}
+
+// ToCredential rebuilds the library's view of a stored credential.
+func ToCredential(c *models.WebAuthnCredential) webauthn.Credential {
+	var transports []protocol.AuthenticatorTransport
+	for _, t := range strings.Fields(c.Transports) {
+		transports = append(transports, protocol.AuthenticatorTransport(t))
+	}
+
+	return webauthn.Credential{
+		ID:              c.CredentialID,
+		PublicKey:       c.PublicKey,
+		AttestationType: c.AttestationType,
+		Transport:       transports,
+		Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(c.Flags)),
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    c.AAGUID,
+			SignCount: c.SignCount,
+		},
+	}
+}
+
+// FromCredential copies a newly registered credential into a model.
+func FromCredential(credential *webauthn.Credential, userID uint, name string) models.WebAuthnCredential {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, t := range credential.Transport {
+		transports = append(transports, string(t))
+	}
+
+	return models.WebAuthnCredential{
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, " "),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Flags:           uint8(credential.Flags.ProtocolValue()),
+		UserID:          userID,
+	}
+}
@@ -0,0 +1,200 @@
+// Package pow implements a hashcash-style proof-of-work challenge that the
+// public auth endpoints can demand from suspicious clients. Challenges are
+// signed by the server, so nothing is stored until one is redeemed.
+package pow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+	"expense-tracker/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Modes for POW_MODE. In ModeAuto a solution is only demanded when a client
+// looks abusive.
+const (
+	ModeOff    = "off"
+	ModeAuto   = "auto"
+	ModeAlways = "always"
+)
+
+var (
+	ErrInvalid  = errors.New("invalid proof of work challenge")
+	ErrExpired  = errors.New("proof of work challenge expired")
+	ErrTooWeak  = errors.New("proof of work does not meet the difficulty")
+	ErrRedeemed = errors.New("proof of work challenge already used")
+)
+
+// Challenge is the signed payload handed to the client.
+type Challenge struct {
+	ID         string `json:"id"`
+	Difficulty int    `json:"d"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+// Policy controls when ModeAuto demands a proof of work: once a client IP or
+// email has AfterFailures recent failed logins, or an IP has registered
+// SignupsPerIP accounts within SignupWindow.
+type Policy struct {
+	AfterFailures int
+	SignupsPerIP  int
+	SignupWindow  time.Duration
+}
+
+// Auto is the policy used in ModeAuto.
+var Auto = Policy{
+	AfterFailures: utils.IntFromEnv("POW_AFTER_FAILURES", 3),
+	SignupsPerIP:  utils.IntFromEnv("POW_SIGNUPS_PER_IP", 3),
+	SignupWindow:  utils.DurationFromEnv("POW_SIGNUP_WINDOW", time.Hour),
+}
+
+var secret = loadSecret()
+
+// Mode returns the configured POW_MODE.
+func Mode() string {
+	switch mode := strings.ToLower(os.Getenv("POW_MODE")); mode {
+	case ModeOff, ModeAlways:
+		return mode
+	default:
+		return ModeAuto
+	}
+}
+
+// Difficulty returns how many leading zero bits a solution's hash needs.
+// Every extra bit doubles the expected work.
+func Difficulty() int {
+	return min(utils.IntFromEnv("POW_DIFFICULTY", 18), 32)
+}
+
+// TTL returns how long a client has to solve a challenge.
+func TTL() time.Duration {
+	return utils.DurationFromEnv("POW_CHALLENGE_TTL", 5*time.Minute)
+}
+
+// Issue creates a new signed challenge.
+func Issue() (string, *Challenge, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+
+	challenge := &Challenge{
+		ID:         base64.RawURLEncoding.EncodeToString(id),
+		Difficulty: Difficulty(),
+		ExpiresAt:  time.Now().Add(TTL()).Unix(),
+	}
+
+	payload, err := json.Marshal(challenge)
+	if err != nil {
+		return "", nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + sign(encoded), challenge, nil
+}
+
+// Verify checks the signature and expiry of token and that
+// SHA-256(token + ":" + nonce) starts with the challenge's number of zero
+// bits. It does not mark the challenge as used; see Redeem.
+func Verify(token, nonce string) (*Challenge, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(encoded))) {
+		return nil, ErrInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	var challenge Challenge
+	if err := json.Unmarshal(payload, &challenge); err != nil {
+		return nil, ErrInvalid
+	}
+
+	if time.Now().Unix() > challenge.ExpiresAt {
+		return nil, ErrExpired
+	}
+
+	if LeadingZeroBits(token, nonce) < challenge.Difficulty {
+		return nil, ErrTooWeak
+	}
+
+	return &challenge, nil
+}
+
+// Redeem marks a verified challenge as used. A second redemption of the same
+// challenge fails with ErrRedeemed.
+func Redeem(challenge *Challenge) error {
+	// Expired rows are useless, so piggyback the cleanup on writes.
+	database.DB.Where("expires_at < ?", time.Now()).Delete(&models.ProofOfWorkRedemption{})
+
+	err := database.DB.Create(&models.ProofOfWorkRedemption{
+		ChallengeID: challenge.ID,
+		ExpiresAt:   time.Unix(challenge.ExpiresAt, 0),
+	}).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrRedeemed
+	}
+	return err
+}
+
+// LeadingZeroBits returns the number of leading zero bits of the solution
+// hash for token and nonce.
+func LeadingZeroBits(token, nonce string) int {
+	sum := sha256.Sum256([]byte(token + ":" + nonce))
+
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
+
+// Solve searches for a nonce satisfying difficulty. It is meant for Go
+// clients and scripts; browsers run the same loop in JavaScript.
+func Solve(token string, difficulty int) string {
+	for i := 0; ; i++ {
+		nonce := strconv.Itoa(i)
+		if LeadingZeroBits(token, nonce) >= difficulty {
+			return nonce
+		}
+	}
+}
+
+func sign(encoded string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// loadSecret reads POW_SECRET. Without it a random key is used, so
+// challenges issued before a restart or by another instance are rejected.
+func loadSecret() []byte {
+	if s := os.Getenv("POW_SECRET"); s != "" {
+		return []byte(s)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal("Failed to generate proof of work secret:", err)
+	}
+	return key
+}
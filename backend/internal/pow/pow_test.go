@@ -0,0 +1,129 @@
+package pow
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/testdb"
+)
+
+// issueEasy issues a challenge cheap enough to solve in tests.
+func issueEasy(t *testing.T) (string, *Challenge) {
+	t.Helper()
+
+	t.Setenv("POW_DIFFICULTY", "8")
+	token, challenge, err := Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge.Difficulty != 8 {
+		t.Fatalf("difficulty = %d, want 8", challenge.Difficulty)
+	}
+	return token, challenge
+}
+
+// signed builds a validly signed token for an arbitrary challenge.
+func signed(t *testing.T, challenge Challenge) string {
+	t.Helper()
+
+	payload, err := json.Marshal(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + sign(encoded)
+}
+
+func TestVerifyAcceptsSolution(t *testing.T) {
+	token, issued := issueEasy(t)
+
+	challenge, err := Verify(token, Solve(token, issued.Difficulty))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if challenge.ID != issued.ID {
+		t.Errorf("verified challenge %q, issued %q", challenge.ID, issued.ID)
+	}
+}
+
+func TestVerifyRejectsWeakNonce(t *testing.T) {
+	token, issued := issueEasy(t)
+
+	nonce := 0
+	for LeadingZeroBits(token, strconv.Itoa(nonce)) >= issued.Difficulty {
+		nonce++
+	}
+	if _, err := Verify(token, strconv.Itoa(nonce)); !errors.Is(err, ErrTooWeak) {
+		t.Errorf("weak nonce: got %v, want ErrTooWeak", err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	token, issued := issueEasy(t)
+	encoded, signature, _ := strings.Cut(token, ".")
+
+	// Lowering the difficulty invalidates the signature.
+	easier := *issued
+	easier.Difficulty = 0
+	payload, _ := json.Marshal(easier)
+	forged := base64.RawURLEncoding.EncodeToString(payload) + "." + signature
+
+	for name, bad := range map[string]string{
+		"forged payload":    forged,
+		"missing signature": encoded,
+		"garbage":           "not-a-token",
+		"bad payload":       "!!!." + sign("!!!"),
+	} {
+		if _, err := Verify(bad, "0"); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: got %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+func TestVerifyRejectsExpired(t *testing.T) {
+	token := signed(t, Challenge{ID: "expired", Difficulty: 0, ExpiresAt: time.Now().Add(-time.Second).Unix()})
+	if _, err := Verify(token, "0"); !errors.Is(err, ErrExpired) {
+		t.Errorf("got %v, want ErrExpired", err)
+	}
+}
+
+func TestRedeemOnlyOnce(t *testing.T) {
+	testdb.Open(t)
+	token, issued := issueEasy(t)
+
+	challenge, err := Verify(token, Solve(token, issued.Difficulty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Redeem(challenge); err != nil {
+		t.Fatalf("first redemption: %v", err)
+	}
+	if err := Redeem(challenge); !errors.Is(err, ErrRedeemed) {
+		t.Errorf("second redemption: got %v, want ErrRedeemed", err)
+	}
+
+	other, otherIssued := issueEasy(t)
+	otherChallenge, err := Verify(other, Solve(other, otherIssued.Difficulty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Redeem(otherChallenge); err != nil {
+		t.Errorf("another challenge: %v", err)
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	// Solve must return a nonce with at least the requested zero bits, and
+	// the count must never exceed the hash size.
+	for difficulty := 0; difficulty <= 12; difficulty += 4 {
+		nonce := Solve("token", difficulty)
+		if got := LeadingZeroBits("token", nonce); got < difficulty || got > 256 {
+			t.Errorf("difficulty %d: nonce %s has %d zero bits", difficulty, nonce, got)
+		}
+	}
+}
\ No newline at end of file
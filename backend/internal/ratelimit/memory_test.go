@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreBurstThenReject(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 3, Period: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		result, err := store.Take("client", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed {
+			t.Fatalf("request %d of the burst was rejected", i+1)
+		}
+		if want := 2 - i; result.Remaining != want {
+			t.Errorf("request %d: remaining = %d, want %d", i+1, result.Remaining, want)
+		}
+		if result.Limit != 3 {
+			t.Errorf("limit = %d, want 3", result.Limit)
+		}
+	}
+
+	result, _ := store.Take("client", limit)
+	if result.Allowed {
+		t.Fatal("request over the limit was allowed")
+	}
+	// One token refills every 20s.
+	if result.RetryAfter <= 19*time.Second || result.RetryAfter > 20*time.Second {
+		t.Errorf("retry after = %v, want about 20s", result.RetryAfter)
+	}
+	if result.ResetAfter <= 59*time.Second || result.ResetAfter > time.Minute {
+		t.Errorf("reset after = %v, want about 1m", result.ResetAfter)
+	}
+
+	if other, _ := store.Take("other-client", limit); !other.Allowed {
+		t.Error("another client was limited too")
+	}
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 2, Period: 200 * time.Millisecond}
+
+	store.Take("client", limit)
+	store.Take("client", limit)
+	if result, _ := store.Take("client", limit); result.Allowed {
+		t.Fatal("bucket should be empty")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if result, _ := store.Take("client", limit); !result.Allowed {
+		t.Error("a token should have refilled after one interval")
+	}
+}
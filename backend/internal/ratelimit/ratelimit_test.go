@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	valid := map[string]Limit{
+		"100/1m":  {Requests: 100, Period: time.Minute},
+		" 5/10s ": {Requests: 5, Period: 10 * time.Second},
+		"":        {},
+		"off":     {},
+	}
+	for input, want := range valid {
+		got, err := ParseLimit(input)
+		if err != nil {
+			t.Errorf("ParseLimit(%q): %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseLimit(%q) = %+v, want %+v", input, got, want)
+		}
+	}
+
+	for _, input := range []string{"100", "x/1m", "-1/1m", "10/0s", "10/soon"} {
+		if _, err := ParseLimit(input); err == nil {
+			t.Errorf("ParseLimit(%q) should fail", input)
+		}
+	}
+}
+
+func TestLimitPolicy(t *testing.T) {
+	if got := (Limit{Requests: 100, Period: time.Minute}).Policy(); got != "100;w=60" {
+		t.Errorf("Policy() = %q", got)
+	}
+	if (Limit{}).Enabled() {
+		t.Error("zero limit should be disabled")
+	}
+}
@@ -0,0 +1,81 @@
+// Package testdb gives tests a throwaway SQLite database in place of
+// PostgreSQL, installed as database.DB.
+package testdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"expense-tracker/internal/database"
+	"expense-tracker/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Models lists every table, in the order the server migrates them.
+var Models = []interface{}{
+	&models.User{},
+	&models.Workspace{},
+	&models.WorkspaceMember{},
+	&models.Category{},
+	&models.Transaction{},
+	&models.Contact{},
+	&models.TransactionSplit{},
+	&models.Settlement{},
+	&models.Session{},
+	&models.RefreshToken{},
+	&models.RevokedToken{},
+	&models.PasswordResetToken{},
+	&models.EmailVerificationToken{},
+	&models.RecoveryCode{},
+	&models.LoginThrottle{},
+	&models.APIToken{},
+	&models.SecurityEvent{},
+	&models.Identity{},
+	&models.OIDCState{},
+	&models.WebAuthnCredential{},
+	&models.WebAuthnSession{},
+	&models.ProofOfWorkRedemption{},
+}
+
+// Open creates a migrated database in the test's temp directory, makes it
+// database.DB for the duration of the test and returns it.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	if err := db.AutoMigrate(Models...); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// CreateUser stores a user with the given email, which also creates their
+// personal workspace.
+func CreateUser(t testing.TB, email string) *models.User {
+	t.Helper()
+
+	user := models.User{Name: "Test User", Email: email, Password: "x"}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &user
+}
@@ -0,0 +1,154 @@
+// Package money represents amounts as integer minor units (cents) so that
+// storing, summing and encoding them is exact.
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimal places of an amount.
+const Scale = 2
+
+const unit = 100 // 10^Scale
+
+var (
+	ErrInvalid   = errors.New("invalid amount")
+	ErrPrecision = fmt.Errorf("amount has more than %d decimal places", Scale)
+	ErrRange     = errors.New("amount out of range")
+)
+
+// Amount is a sum of money in minor units. It is stored as a bigint and
+// encoded in JSON as a decimal number such as 12.30.
+type Amount int64
+
+// Parse reads a decimal string such as "12.3", "-0.05" or "7".
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return 0, ErrInvalid
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > Scale {
+		return 0, ErrPrecision
+	}
+	fraction += strings.Repeat("0", Scale-len(fraction))
+
+	if whole == "" {
+		whole = "0"
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/unit-1 {
+		return 0, ErrRange
+	}
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+
+	amount := Amount(units*unit + cents)
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// String formats the amount with exactly Scale decimal places.
+func (a Amount) String() string {
+	sign := ""
+	value := int64(a)
+	if value < 0 {
+		sign = "-"
+	}
+
+	// Work on the unsigned magnitude so math.MinInt64 does not overflow.
+	magnitude := uint64(value)
+	if value < 0 {
+		magnitude = -magnitude
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, magnitude/unit, Scale, magnitude%unit)
+}
+
+// Float returns the amount in major units, for ratios such as percentages.
+func (a Amount) Float() float64 {
+	return float64(a) / unit
+}
+
+// MarshalJSON encodes the amount as a JSON number with Scale decimals.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding a decimal. Values
+// with more than Scale decimal places are rejected rather than rounded.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Value stores the amount as its number of minor units.
+func (a Amount) Value() (driver.Value, error) {
+	return int64(a), nil
+}
+
+// Scan reads a number of minor units, including the numeric results of SUM
+// over bigint columns.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+	case int64:
+		*a = Amount(v)
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	return nil
+}
+
+func (a *Amount) scanString(s string) error {
+	whole, fraction, _ := strings.Cut(s, ".")
+	if strings.Trim(fraction, "0") != "" {
+		return fmt.Errorf("money: %q is not a whole number of minor units", s)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return fmt.Errorf("money: %w", err)
+	}
+	*a = Amount(units)
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
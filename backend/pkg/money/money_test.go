@@ -0,0 +1,151 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for input, want := range map[string]Amount{
+		"7":       700,
+		"12.3":    1230,
+		"12.30":   1230,
+		"-0.05":   -5,
+		".5":      50,
+		"3.":      300,
+		" 1.99 ":  199,
+		"99.000":  9900,
+		"0":       0,
+		"1000000": 100000000,
+	} {
+		got, err := Parse(input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Parse(%q) = %d, want %d", input, got, want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for input, want := range map[string]error{
+		"":                     ErrInvalid,
+		".":                    ErrInvalid,
+		"-":                    ErrInvalid,
+		"abc":                  ErrInvalid,
+		"1,50":                 ErrInvalid,
+		"1e3":                  ErrInvalid,
+		"+1":                   ErrInvalid,
+		"--1":                  ErrInvalid,
+		"1.2.3":                ErrInvalid,
+		"0.001":                ErrPrecision,
+		"10.005":               ErrPrecision,
+		"99999999999999999999": ErrRange,
+	} {
+		if _, err := Parse(input); !errors.Is(err, want) {
+			t.Errorf("Parse(%q): got %v, want %v", input, err, want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	for amount, want := range map[Amount]string{
+		0:                     "0.00",
+		5:                     "0.05",
+		-5:                    "-0.05",
+		1230:                  "12.30",
+		-123456:               "-1234.56",
+		math.MinInt64:         "-92233720368547758.08",
+		Amount(math.MaxInt64): "92233720368547758.07",
+	} {
+		if got := amount.String(); got != want {
+			t.Errorf("Amount(%d).String() = %q, want %q", int64(amount), got, want)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type body struct {
+		Amount Amount `json:"amount"`
+	}
+
+	encoded, err := json.Marshal(body{Amount: 1230})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `{"amount":12.30}` {
+		t.Errorf("Marshal = %s", encoded)
+	}
+
+	for input, want := range map[string]Amount{
+		`{"amount":12.3}`:    1230,
+		`{"amount":"12.30"}`: 1230,
+		`{"amount":-0.01}`:   -1,
+		`{"amount":null}`:    0,
+		`{}`:                 0,
+	} {
+		var decoded body
+		if err := json.Unmarshal([]byte(input), &decoded); err != nil {
+			t.Errorf("Unmarshal(%s): %v", input, err)
+			continue
+		}
+		if decoded.Amount != want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", input, decoded.Amount, want)
+		}
+	}
+}
+
+func TestUnmarshalRejectsExtraPrecision(t *testing.T) {
+	var amount Amount
+	if err := json.Unmarshal([]byte(`0.125`), &amount); !errors.Is(err, ErrPrecision) {
+		t.Errorf("0.125: got %v, want ErrPrecision", err)
+	}
+	if err := json.Unmarshal([]byte(`"ten"`), &amount); !errors.Is(err, ErrInvalid) {
+		t.Errorf(`"ten": got %v, want ErrInvalid`, err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	for _, tc := range []struct {
+		src  interface{}
+		want Amount
+	}{
+		{nil, 0},
+		{int64(1230), 1230},
+		{int64(-5), -5},
+		{[]byte("12345"), 12345},
+		{"12345", 12345},
+		// SUM over bigint columns comes back as numeric on postgres.
+		{"99.000", 99},
+		{[]byte("-7.0"), -7},
+	} {
+		amount := Amount(42)
+		if err := amount.Scan(tc.src); err != nil {
+			t.Errorf("Scan(%#v): %v", tc.src, err)
+			continue
+		}
+		if amount != tc.want {
+			t.Errorf("Scan(%#v) = %d, want %d", tc.src, amount, tc.want)
+		}
+	}
+}
+
+func TestScanRejects(t *testing.T) {
+	for _, src := range []interface{}{"1.5", []byte("abc"), 1.5, true} {
+		var amount Amount
+		if err := amount.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) succeeded with %d", src, amount)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	value, err := Amount(-1230).Value()
+	if err != nil || value != int64(-1230) {
+		t.Errorf("Value() = %#v, %v", value, err)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsPasswordBreached looks the password up in the local breached-password
+// list at BREACHED_PASSWORDS_PATH, in the Have I Been Pwned SHA-1 format. No
+// network call is made. The path may be either
+//
+//   - a directory of range files named <first 5 hex chars>.txt, each line
+//     holding the remaining 35 hex chars of a hash, optionally ":<count>"
+//     (the per-prefix layout of the official downloader), or
+//   - a single file with one full "<hash>:<count>" line per hash, sorted by
+//     hash, which is searched by binary search.
+//
+// With no path configured every password passes.
+func IsPasswordBreached(password string) (bool, error) {
+	path := os.Getenv("BREACHED_PASSWORDS_PATH")
+	if path == "" {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if info.IsDir() {
+		return breachedInRangeFile(filepath.Join(path, hash[:5]+".txt"), hash[5:])
+	}
+	return breachedInSortedFile(path, info.Size(), hash)
+}
+
+func breachedInRangeFile(path, suffix string) (bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if hashField(scanner.Text()) == suffix {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+func breachedInSortedFile(path string, size int64, hash string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	// lo is always the start of a line; the answer, if any, starts in
+	// [lo, hi).
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, line, err := lineAfter(f, mid)
+		if err != nil {
+			return false, err
+		}
+		if line == "" {
+			hi = mid
+			continue
+		}
+
+		switch candidate := hashField(line); {
+		case candidate == hash:
+			return true, nil
+		case candidate < hash:
+			lo = start + int64(len(line))
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// lineAfter returns the first line starting at or after offset, along with
+// its offset. The returned line includes its trailing newline so callers can
+// step over it; it is empty at end of file.
+func lineAfter(f *os.File, offset int64) (int64, string, error) {
+	start := offset
+	if offset > 0 {
+		start = offset - 1
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return 0, "", err
+	}
+	r := bufio.NewReader(f)
+
+	// Skip the rest of the line containing offset-1; if that byte is a
+	// newline, offset itself starts a line.
+	if offset > 0 {
+		skipped, err := r.ReadString('\n')
+		if err == io.EOF {
+			return 0, "", nil
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	return start, line, nil
+}
+
+// hashField returns the upper-cased hash part of a "<hash>:<count>" line.
+func hashField(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return strings.ToUpper(hash)
+}
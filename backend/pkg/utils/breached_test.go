@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeSortedList writes hashes as a sorted "<hash>:<count>" file.
+func writeSortedList(t *testing.T, hashes []string, newline string, trailing bool) string {
+	t.Helper()
+
+	sorted := append([]string(nil), hashes...)
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	for i, hash := range sorted {
+		fmt.Fprintf(&b, "%s:%d", hash, i+1)
+		if trailing || i < len(sorted)-1 {
+			b.WriteString(newline)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func randomHashes(n int) []string {
+	r := rand.New(rand.NewSource(1))
+	hashes := make([]string, n)
+	for i := range hashes {
+		hashes[i] = sha1Hex(fmt.Sprint(r.Int63()))
+	}
+	return hashes
+}
+
+func TestBreachedInSortedFileFindsEveryLine(t *testing.T) {
+	hashes := randomHashes(500)
+
+	for _, layout := range []struct {
+		name     string
+		newline  string
+		trailing bool
+	}{
+		{"lf", "\n", true},
+		{"crlf", "\r\n", true},
+		{"no trailing newline", "\n", false},
+	} {
+		t.Run(layout.name, func(t *testing.T) {
+			path := writeSortedList(t, hashes, layout.newline, layout.trailing)
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, hash := range hashes {
+				found, err := breachedInSortedFile(path, info.Size(), hash)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !found {
+					t.Fatalf("hash %s in the list was not found", hash)
+				}
+			}
+
+			for _, missing := range []string{strings.Repeat("0", 40), strings.Repeat("F", 40), sha1Hex("not in the list")} {
+				found, err := breachedInSortedFile(path, info.Size(), missing)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if found {
+					t.Errorf("hash %s not in the list was found", missing)
+				}
+			}
+		})
+	}
+}
+
+func TestBreachedInSortedFileTinyLists(t *testing.T) {
+	hash := sha1Hex("password")
+	for _, hashes := range [][]string{{}, {hash}, {strings.Repeat("0", 40), hash}} {
+		path := writeSortedList(t, hashes, "\n", true)
+		info, _ := os.Stat(path)
+
+		found, err := breachedInSortedFile(path, info.Size(), hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := len(hashes) > 0; found != want {
+			t.Errorf("list of %d: found = %v, want %v", len(hashes), found, want)
+		}
+	}
+}
+
+func TestIsPasswordBreached(t *testing.T) {
+	hashes := append(randomHashes(50), sha1Hex("password123"))
+
+	t.Run("sorted file", func(t *testing.T) {
+		t.Setenv("BREACHED_PASSWORDS_PATH", writeSortedList(t, hashes, "\n", true))
+		assertBreached(t, "password123", true)
+		assertBreached(t, "plum-harbor-lantern-91", false)
+	})
+
+	t.Run("range directory", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, hash := range hashes {
+			f, err := os.OpenFile(filepath.Join(dir, hash[:5]+".txt"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Suffixes match regardless of case.
+			fmt.Fprintf(f, "%s:3\n", strings.ToLower(hash[5:]))
+			f.Close()
+		}
+		t.Setenv("BREACHED_PASSWORDS_PATH", dir)
+		assertBreached(t, "password123", true)
+		assertBreached(t, "plum-harbor-lantern-91", false)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		t.Setenv("BREACHED_PASSWORDS_PATH", "")
+		assertBreached(t, "password123", false)
+	})
+}
+
+func assertBreached(t *testing.T, password string, want bool) {
+	t.Helper()
+
+	got, err := IsPasswordBreached(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("IsPasswordBreached(%q) = %v, want %v", password, got, want)
+	}
+}
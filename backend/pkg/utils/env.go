@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// IntFromEnv returns the positive integer in the environment variable key,
+// or fallback when it is unset or invalid.
+func IntFromEnv(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
+
+// DurationFromEnv returns the positive duration (such as "15m") in the
+// environment variable key, or fallback when it is unset or invalid.
+func DurationFromEnv(key string, fallback time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntFromEnv(t *testing.T) {
+	for value, want := range map[string]int{"": 7, "12": 12, "0": 7, "-3": 7, "x": 7} {
+		t.Setenv("TEST_INT", value)
+		if got := IntFromEnv("TEST_INT", 7); got != want {
+			t.Errorf("IntFromEnv with %q = %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestDurationFromEnv(t *testing.T) {
+	for value, want := range map[string]time.Duration{"": time.Minute, "90s": 90 * time.Second, "0s": time.Minute, "-1m": time.Minute, "10": time.Minute} {
+		t.Setenv("TEST_DURATION", value)
+		if got := DurationFromEnv("TEST_DURATION", time.Minute); got != want {
+			t.Errorf("DurationFromEnv with %q = %v, want %v", value, got, want)
+		}
+	}
+}
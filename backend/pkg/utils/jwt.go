@@ -2,7 +2,6 @@ package utils
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,55 +10,152 @@ import (
 type Claims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
+	// Role is the user's role when the token was issued.
+	Role string `json:"role,omitempty"`
+	// SessionID ties an access token to a models.Session so that revoking
+	// the session revokes the token.
+	SessionID uint `json:"sid,omitempty"`
+	// Purpose is empty for access tokens. Tokens with a purpose (such as
+	// PurposeMFA) are only accepted by the endpoint they were issued for.
+	Purpose string `json:"purpose,omitempty"`
+	// ImpersonatorID and ImpersonatorEmail name the admin acting as the user
+	// on an impersonation token. ReadOnly tokens cannot change anything.
+	ImpersonatorID    uint   `json:"imp_id,omitempty"`
+	ImpersonatorEmail string `json:"imp_email,omitempty"`
+	ReadOnly          bool   `json:"read_only,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Token times carry milliseconds so that a token issued right after a
+// "log out everywhere" cutoff is not mistaken for one issued before it.
+func init() {
+	jwt.TimePrecision = time.Millisecond
+}
 
-func GenerateToken(userID uint, email string) (string, error) {
-	// Get JWT secret from environment
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", errors.New("JWT_SECRET not set in environment")
-	}
+// PurposeMFA marks a token proving the password step of a two-factor login.
+const PurposeMFA = "mfa"
 
-	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // Token expires in 24 hours
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
-	}
+// MFATokenTTL is how long a user has to enter their second factor.
+const MFATokenTTL = 5 * time.Minute
+
+// PurposeMagicLink marks a token emailed as a passwordless login link.
+const PurposeMagicLink = "magic_link"
+
+// PurposeOIDCLogin marks the one-time code handed to the frontend after an
+// OpenID Connect login, which it exchanges for a session.
+const PurposeOIDCLogin = "oidc_login"
+
+// OIDCLoginTokenTTL is how long the frontend has to exchange that code.
+const OIDCLoginTokenTTL = 2 * time.Minute
+
+// AccessTokenTTL returns how long an access token stays valid.
+func AccessTokenTTL() time.Duration {
+	return DurationFromEnv("JWT_ACCESS_TTL", 15*time.Minute)
+}
+
+func GenerateToken(userID uint, email, role string, sessionID uint) (string, error) {
+	return generateToken(userID, email, role, sessionID, "", AccessTokenTTL())
+}
+
+// GenerateMFAToken issues the short-lived token returned by Login when the
+// user still has to pass the second factor.
+func GenerateMFAToken(userID uint, email string) (string, error) {
+	return generateToken(userID, email, "", 0, PurposeMFA, MFATokenTTL)
+}
+
+// GenerateMagicLinkToken issues the signed token embedded in a magic login
+// link. It is bound to the email it was sent to.
+func GenerateMagicLinkToken(userID uint, email string) (string, error) {
+	return generateToken(userID, email, "", 0, PurposeMagicLink, MagicLinkTTL())
+}
+
+// GenerateOIDCLoginToken issues the one-time code for an OIDC login.
+func GenerateOIDCLoginToken(userID uint, email string) (string, error) {
+	return generateToken(userID, email, "", 0, PurposeOIDCLogin, OIDCLoginTokenTTL)
+}
+
+// GenerateImpersonationToken issues an access token for userID that records
+// the admin acting as them. It is not tied to a session and cannot be
+// refreshed.
+func GenerateImpersonationToken(userID uint, email, role string, adminID uint, adminEmail string, readOnly bool) (string, error) {
+	return signClaims(Claims{
+		UserID:            userID,
+		Email:             email,
+		Role:              role,
+		ImpersonatorID:    adminID,
+		ImpersonatorEmail: adminEmail,
+		ReadOnly:          readOnly,
+	}, ImpersonationTTL())
+}
+
+func generateToken(userID uint, email, role string, sessionID uint, purpose string, ttl time.Duration) (string, error) {
+	return signClaims(Claims{
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
+		Purpose:   purpose,
+	}, ttl)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+func signClaims(claims Claims, ttl time.Duration) (string, error) {
+	keys, err := getKeys()
+	if err != nil {
+		return "", err
+	}
 
-	tokenString, err := token.SignedString([]byte(secret))
+	jti, err := GenerateRandomToken(16)
 	if err != nil {
 		return "", err
 	}
 
-	return tokenString, nil
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        jti,
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+
+	return keys.sign(claims)
 }
 
+// ValidateToken parses an access token. Tokens issued for another purpose
+// are rejected.
 func ValidateToken(tokenString string) (*Claims, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, errors.New("JWT_SECRET not set in environment")
-	}
+	return validateToken(tokenString, "")
+}
 
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
+// ValidateMFAToken parses a token issued by GenerateMFAToken.
+func ValidateMFAToken(tokenString string) (*Claims, error) {
+	return validateToken(tokenString, PurposeMFA)
+}
 
+// ValidateMagicLinkToken parses a token issued by GenerateMagicLinkToken.
+func ValidateMagicLinkToken(tokenString string) (*Claims, error) {
+	return validateToken(tokenString, PurposeMagicLink)
+}
+
+// ValidateOIDCLoginToken parses a token issued by GenerateOIDCLoginToken.
+func ValidateOIDCLoginToken(tokenString string) (*Claims, error) {
+	return validateToken(tokenString, PurposeOIDCLogin)
+}
+
+func validateToken(tokenString, purpose string) (*Claims, error) {
+	keys, err := getKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.keyFunc)
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if claims.Purpose != purpose {
+			return nil, errors.New("token issued for a different purpose")
+		}
 		return claims, nil
 	}
 
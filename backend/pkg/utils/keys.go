@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is one entry of the key set. Private is nil for keys that are
+// only kept around to verify tokens signed before a rotation.
+type signingKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// keySet holds the keys used to sign and verify tokens.
+//
+// With JWT_KEYS_DIR set, every <kid>.pem file in it (RSA or Ed25519, private
+// or public) is a verification key and JWT_ACTIVE_KID names the private key
+// used for signing. Rotation means adding a new key, switching
+// JWT_ACTIVE_KID, and deleting the old file once its tokens have expired.
+//
+// Without JWT_KEYS_DIR tokens are signed with HS256 and JWT_SECRET. When both
+// are set, HS256 tokens are still accepted so existing sessions survive the
+// switch to asymmetric keys.
+type keySet struct {
+	active     *signingKey
+	keys       map[string]*signingKey
+	hmacSecret []byte
+}
+
+var (
+	keysOnce sync.Once
+	keys     *keySet
+	keysErr  error
+)
+
+// LoadKeys loads the key set from the environment. It is called lazily by
+// token functions; calling it at startup surfaces configuration errors early.
+func LoadKeys() error {
+	keysOnce.Do(func() {
+		keys, keysErr = loadKeySet(os.Getenv("JWT_KEYS_DIR"), os.Getenv("JWT_ACTIVE_KID"), os.Getenv("JWT_SECRET"))
+	})
+	return keysErr
+}
+
+func getKeys() (*keySet, error) {
+	if err := LoadKeys(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func loadKeySet(dir, activeKID, secret string) (*keySet, error) {
+	set := &keySet{keys: make(map[string]*signingKey)}
+	if secret != "" {
+		set.hmacSecret = []byte(secret)
+	}
+
+	if dir == "" {
+		if set.hmacSecret == nil {
+			return nil, errors.New("JWT_SECRET not set in environment")
+		}
+		return set, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := loadKeyFile(kid, file)
+		if err != nil {
+			return nil, err
+		}
+		set.keys[kid] = key
+	}
+
+	if activeKID == "" {
+		return nil, errors.New("JWT_ACTIVE_KID must be set when JWT_KEYS_DIR is used")
+	}
+	active, ok := set.keys[activeKID]
+	if !ok || active.Private == nil {
+		return nil, fmt.Errorf("no private key %s.pem in %s", activeKID, dir)
+	}
+	set.active = active
+
+	return set, nil
+}
+
+func loadKeyFile(kid, path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	key := &signingKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("%s: unsupported key type %T", path, parsed)
+	}
+	return key, nil
+}
+
+// sign signs claims with the active key, or with JWT_SECRET when no
+// asymmetric keys are configured.
+func (s *keySet) sign(claims jwt.Claims) (string, error) {
+	if s.active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.hmacSecret)
+	}
+
+	token := jwt.NewWithClaims(s.active.Method, claims)
+	token.Header["kid"] = s.active.ID
+	return token.SignedString(s.active.Private)
+}
+
+// keyFunc picks the verification key for a token by its kid header.
+func (s *keySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if s.hmacSecret == nil {
+			return nil, errors.New("unexpected signing method")
+		}
+		return s.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public, nil
+}
+
+// JWKS returns the public verification keys as a JSON Web Key Set (RFC 7517)
+// so other services can verify tokens without sharing a secret. HMAC secrets
+// are never published.
+func JWKS() (map[string]interface{}, error) {
+	set, err := getKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	kids := make([]string, 0, len(set.keys))
+	for kid := range set.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := []map[string]string{}
+	for _, kid := range kids {
+		key := set.keys[kid]
+		jwk := map[string]string{
+			"kid": kid,
+			"use": "sig",
+			"alg": key.Method.Alg(),
+		}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		jwks = append(jwks, jwk)
+	}
+
+	return map[string]interface{}{"keys": jwks}, nil
+}
@@ -1,15 +1,210 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// PasswordHasher is one password hashing scheme. Hashes are self-describing
+// (bcrypt's "$2a$<cost>$..." and the PHC "$argon2id$v=19$m=..,t=..,p=..$..."
+// format), so stored hashes can be verified after the configured scheme or
+// its parameters change, and NeedsRehash can spot outdated ones.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Recognizes reports whether hash was produced by this scheme.
+	Recognizes(hash string) bool
+	Verify(password, hash string) bool
+	// NeedsRehash reports whether hash was produced by this scheme with
+	// different parameters than the current ones.
+	NeedsRehash(hash string) bool
+}
+
+// Argon2idHasher hashes with Argon2id (RFC 9106). Memory is in KiB.
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h Argon2idHasher) Verify(password, hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+
+	return params.Memory != h.Memory ||
+		params.Iterations != h.Iterations ||
+		params.Parallelism != h.Parallelism ||
+		uint32(len(salt)) != h.SaltLength ||
+		uint32(len(key)) != h.KeyLength
+}
+
+func decodeArgon2id(hash string) (Argon2idHasher, []byte, []byte, error) {
+	var params Argon2idHasher
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// bcryptMaxBytes is the longest password bcrypt accepts.
+const bcryptMaxBytes = 72
+
+// BcryptHasher hashes with bcrypt at the given cost. It cannot hash
+// passwords longer than bcryptMaxBytes, so the password policy caps them
+// when it is the configured hasher.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
 	return string(bytes), err
 }
 
-func CheckPasswordHash(password, hash string) bool {
+func (h BcryptHasher) Recognizes(hash string) bool {
+	_, err := bcrypt.Cost([]byte(hash))
+	return err == nil
+}
+
+func (h BcryptHasher) Verify(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
-}
\ No newline at end of file
+}
+
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost != h.Cost
+}
+
+var (
+	hasherOnce sync.Once
+	hasher     PasswordHasher
+	hashers    []PasswordHasher
+)
+
+// passwordHashers returns the hasher used for new hashes, chosen with
+// PASSWORD_HASH_ALGORITHM ("argon2id" by default, or "bcrypt"), and every
+// hasher able to verify stored hashes.
+func passwordHashers() (PasswordHasher, []PasswordHasher) {
+	hasherOnce.Do(func() {
+		argon := Argon2idHasher{
+			Memory:      uint32(IntFromEnv("ARGON2_MEMORY_KIB", 64*1024)),
+			Iterations:  uint32(IntFromEnv("ARGON2_ITERATIONS", 3)),
+			Parallelism: uint8(IntFromEnv("ARGON2_PARALLELISM", 2)),
+			SaltLength:  16,
+			KeyLength:   32,
+		}
+		bc := BcryptHasher{Cost: IntFromEnv("BCRYPT_COST", bcrypt.DefaultCost)}
+
+		hashers = []PasswordHasher{argon, bc}
+		hasher = argon
+		if os.Getenv("PASSWORD_HASH_ALGORITHM") == "bcrypt" {
+			hasher = bc
+		}
+	})
+	return hasher, hashers
+}
+
+func HashPassword(password string) (string, error) {
+	current, _ := passwordHashers()
+	return current.Hash(password)
+}
+
+func CheckPasswordHash(password, hash string) bool {
+	_, all := passwordHashers()
+	for _, h := range all {
+		if h.Recognizes(hash) {
+			return h.Verify(password, hash)
+		}
+	}
+	return false
+}
+
+// PasswordNeedsRehash reports whether hash was made with another algorithm
+// or other parameters than the configured ones. Callers holding the plain
+// password (i.e. right after a successful login) should then store a new hash.
+func PasswordNeedsRehash(hash string) bool {
+	current, _ := passwordHashers()
+	if !current.Recognizes(hash) {
+		return true
+	}
+	return current.NeedsRehash(hash)
+}
+
+var (
+	dummyOnce sync.Once
+	dummyHash string
+)
+
+// DummyPasswordCheck burns the same time as CheckPasswordHash against a
+// freshly hashed password. It is used when no user matches the email, so
+// unknown emails take as long to reject as wrong passwords.
+func DummyPasswordCheck(password string) {
+	dummyOnce.Do(func() {
+		dummyHash, _ = HashPassword("dummy-password")
+	})
+	CheckPasswordHash(password, dummyHash)
+}
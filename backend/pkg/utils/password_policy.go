@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// PasswordPolicy describes what a new password must satisfy. Lengths count
+// characters, except MaxBytes, which is the most the password hasher can
+// take (zero for no limit). MinStrength is a zxcvbn score from 0 (trivially
+// guessable) to 4 (very strong).
+type PasswordPolicy struct {
+	MinLength   int
+	MaxLength   int
+	MaxBytes    int
+	MinStrength int
+}
+
+var (
+	policyOnce sync.Once
+	policy     PasswordPolicy
+)
+
+// CurrentPasswordPolicy returns the policy configured through
+// PASSWORD_MIN_LENGTH and PASSWORD_MIN_STRENGTH.
+func CurrentPasswordPolicy() PasswordPolicy {
+	policyOnce.Do(func() {
+		policy = PasswordPolicy{
+			MinLength:   IntFromEnv("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:   128,
+			MinStrength: 2,
+		}
+		current, _ := passwordHashers()
+		if _, ok := current.(BcryptHasher); ok {
+			policy.MaxBytes = bcryptMaxBytes
+		}
+		if v, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_STRENGTH")); err == nil && v >= 0 && v <= 4 {
+			policy.MinStrength = v
+		}
+	})
+	return policy
+}
+
+// ValidatePassword checks a new password against the configured policy and
+// the breached-password list. email and name are the account's own details,
+// which the password must not contain. The returned error is safe to show to
+// the user.
+func ValidatePassword(password, email, name string) error {
+	p := CurrentPasswordPolicy()
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("Password must be at least %d characters long", p.MinLength)
+	}
+	if length > p.MaxLength {
+		return fmt.Errorf("Password must be at most %d characters long", p.MaxLength)
+	}
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		return fmt.Errorf("Password must be at most %d bytes long", p.MaxBytes)
+	}
+
+	userInputs := personalTokens(email, name)
+	lower := strings.ToLower(password)
+	for _, token := range userInputs {
+		if strings.Contains(lower, token) {
+			return errors.New("Password must not contain your name or email address")
+		}
+	}
+
+	if zxcvbn.PasswordStrength(password, userInputs).Score < p.MinStrength {
+		return errors.New("Password is too easy to guess, try a longer passphrase")
+	}
+
+	breached, err := IsPasswordBreached(password)
+	if err != nil {
+		// The list is a defence in depth, so a broken file should not block
+		// every registration.
+		log.Printf("⚠️  Breached password check failed: %v", err)
+	}
+	if breached {
+		return errors.New("This password has appeared in a data breach, please choose a different one")
+	}
+
+	return nil
+}
+
+// personalTokens splits an email and name into the lowercase fragments a
+// password must not contain. Fragments shorter than three characters are too
+// common to reject on.
+func personalTokens(email, name string) []string {
+	var tokens []string
+	add := func(s string) {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if utf8.RuneCountInString(s) >= 3 {
+			tokens = append(tokens, s)
+		}
+	}
+
+	add(email)
+	if local, _, ok := strings.Cut(email, "@"); ok {
+		add(local)
+	}
+	for _, part := range strings.Fields(name) {
+		add(part)
+	}
+	return tokens
+}
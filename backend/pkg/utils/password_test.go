@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// configureHashing sets the hashing environment and forgets the hasher and
+// password policy built from the previous one.
+func configureHashing(t *testing.T, env map[string]string) {
+	t.Helper()
+
+	reset := func() {
+		hasherOnce = sync.Once{}
+		policyOnce = sync.Once{}
+	}
+	t.Cleanup(reset)
+
+	t.Setenv("ARGON2_MEMORY_KIB", "1024")
+	t.Setenv("ARGON2_ITERATIONS", "1")
+	t.Setenv("ARGON2_PARALLELISM", "1")
+	t.Setenv("BCRYPT_COST", "4")
+	t.Setenv("PASSWORD_HASH_ALGORITHM", "")
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	reset()
+}
+
+func TestHasherSelection(t *testing.T) {
+	for algorithm, prefix := range map[string]string{"": "$argon2id$", "argon2id": "$argon2id$", "bcrypt": "$2a$04$"} {
+		configureHashing(t, map[string]string{"PASSWORD_HASH_ALGORITHM": algorithm})
+
+		hash, err := HashPassword("correct horse battery staple")
+		if err != nil {
+			t.Fatalf("%q: %v", algorithm, err)
+		}
+		if !strings.HasPrefix(hash, prefix) {
+			t.Errorf("%q: hash %q, want prefix %q", algorithm, hash, prefix)
+		}
+		if !CheckPasswordHash("correct horse battery staple", hash) || CheckPasswordHash("wrong", hash) {
+			t.Errorf("%q: hash does not verify", algorithm)
+		}
+		if PasswordNeedsRehash(hash) {
+			t.Errorf("%q: a fresh hash needs rehashing", algorithm)
+		}
+	}
+}
+
+func TestHashesVerifyAfterSwitching(t *testing.T) {
+	configureHashing(t, map[string]string{"PASSWORD_HASH_ALGORITHM": "bcrypt"})
+	bcryptHash, _ := HashPassword("switch me please")
+
+	configureHashing(t, nil)
+	if !CheckPasswordHash("switch me please", bcryptHash) {
+		t.Error("bcrypt hash no longer verifies with argon2id configured")
+	}
+	if !PasswordNeedsRehash(bcryptHash) {
+		t.Error("bcrypt hash does not need rehashing with argon2id configured")
+	}
+	if CheckPasswordHash("switch me please", "$unknown$hash") {
+		t.Error("an unrecognized hash verified")
+	}
+}
+
+func TestNeedsRehashOnParameterChange(t *testing.T) {
+	configureHashing(t, nil)
+	argonHash, _ := HashPassword("parameters change")
+
+	configureHashing(t, map[string]string{"ARGON2_ITERATIONS": "2"})
+	if !PasswordNeedsRehash(argonHash) {
+		t.Error("argon2id hash with old iterations does not need rehashing")
+	}
+	if !CheckPasswordHash("parameters change", argonHash) {
+		t.Error("argon2id hash with old iterations no longer verifies")
+	}
+
+	old, _ := bcrypt.GenerateFromPassword([]byte("cost change"), 5)
+	bc := BcryptHasher{Cost: 4}
+	if !bc.NeedsRehash(string(old)) {
+		t.Error("bcrypt hash with another cost does not need rehashing")
+	}
+}
+
+func TestPasswordPolicyCapsBcryptLength(t *testing.T) {
+	long := strings.Repeat("orbit lantern ", 6) // 84 bytes
+	multibyte := strings.Repeat("ünïcödé ", 7)  // 56 characters, 105 bytes
+
+	configureHashing(t, map[string]string{"PASSWORD_HASH_ALGORITHM": "bcrypt"})
+	for _, password := range []string{long, multibyte} {
+		if err := ValidatePassword(password, "user@example.com", "User"); err == nil || !strings.Contains(err.Error(), "72 bytes") {
+			t.Errorf("bcrypt accepted %d bytes: %v", len(password), err)
+		}
+	}
+	if _, err := HashPassword(strings.Repeat("a", 72)); err != nil {
+		t.Errorf("bcrypt rejected 72 bytes: %v", err)
+	}
+
+	configureHashing(t, nil)
+	if err := ValidatePassword(long, "user@example.com", "User"); err != nil {
+		t.Errorf("argon2id rejected a long password: %v", err)
+	}
+	if _, err := HashPassword(long); err != nil {
+		t.Errorf("argon2id failed to hash a long password: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// GenerateRandomToken returns a URL-safe random string built from n bytes of
+// entropy.
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 of an opaque token so only the
+// digest has to be stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RefreshTokenTTL returns how long a refresh token stays valid.
+func RefreshTokenTTL() time.Duration {
+	return DurationFromEnv("JWT_REFRESH_TTL", 30*24*time.Hour)
+}
+
+// PasswordResetTTL returns how long a password reset link stays valid.
+func PasswordResetTTL() time.Duration {
+	return DurationFromEnv("PASSWORD_RESET_TTL", time.Hour)
+}
+
+// MagicLinkTTL returns how long a passwordless login link stays valid.
+func MagicLinkTTL() time.Duration {
+	return DurationFromEnv("MAGIC_LINK_TTL", 15*time.Minute)
+}
+
+// ImpersonationTTL returns how long an admin impersonation token stays valid.
+func ImpersonationTTL() time.Duration {
+	return DurationFromEnv("IMPERSONATION_TTL", 30*time.Minute)
+}
+
+// EmailVerificationTTL returns how long an email verification link stays
+// valid.
+func EmailVerificationTTL() time.Duration {
+	return DurationFromEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+}
+
+// AccountDeletionGrace returns how long a deleted account can still be
+// restored by logging in.
+func AccountDeletionGrace() time.Duration {
+	return DurationFromEnv("ACCOUNT_DELETION_GRACE", 30*24*time.Hour)
+}
+
+// ReauthMaxAge returns how recently an account without a password must have
+// signed in to make sensitive changes without a TOTP code.
+func ReauthMaxAge() time.Duration {
+	return DurationFromEnv("REAUTH_MAX_AGE", 10*time.Minute)
+}
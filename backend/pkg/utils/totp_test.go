@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key from RFC 6238 appendix B, base32-encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPVectors(t *testing.T) {
+	// The RFC lists 8-digit codes; 6-digit codes are their last six digits.
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, v := range vectors {
+		step, ok := ValidateTOTP(rfc6238Secret, v.code, time.Unix(v.unix, 0))
+		if !ok {
+			t.Errorf("code %s at %d was rejected", v.code, v.unix)
+			continue
+		}
+		if step != v.unix/totpPeriod {
+			t.Errorf("code %s at %d matched step %d, want %d", v.code, v.unix, step, v.unix/totpPeriod)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	if _, ok := ValidateTOTP(rfc6238Secret, "005924", now.Add(totpPeriod*time.Second)); !ok {
+		t.Error("code from the previous step should be accepted")
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, "005924", now.Add(3*totpPeriod*time.Second)); ok {
+		t.Error("code from three steps ago should be rejected")
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, "05924", now); ok {
+		t.Error("short code should be rejected")
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, "000000", now); ok {
+		t.Error("wrong code should be rejected")
+	}
+}
+
+func TestGenerateTOTPSecretValidates(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code, err := TOTPCode(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ValidateTOTP(secret, code, now); !ok {
+		t.Error("code for a generated secret was rejected")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q does not look like xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+
+	code := codes[0]
+	stored := HashRecoveryCode(code)
+	typed := []string{
+		code,
+		strings.ToUpper(code),
+		strings.ReplaceAll(code, "-", ""),
+		" " + code[:5] + " " + code[6:] + " ",
+	}
+	for _, input := range typed {
+		if HashRecoveryCode(input) != stored {
+			t.Errorf("typed %q does not match code %q", input, code)
+		}
+	}
+
+	if HashRecoveryCode(codes[1]) == stored {
+		t.Error("a different code matched")
+	}
+}
@@ -1,6 +1,6 @@
 'use client';
 
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { useRouter } from 'next/navigation';
 import Link from 'next/link';
 import { authService } from '@/lib/auth.service';
@@ -11,8 +11,15 @@ export default function LoginPage() {
     email: '',
     password: '',
   });
+  const [mfaToken, setMfaToken] = useState('');
+  const [mfaCode, setMfaCode] = useState('');
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
+  const [providers, setProviders] = useState<string[]>([]);
+
+  useEffect(() => {
+    authService.getOIDCProviders().then(setProviders).catch(() => setProviders([]));
+  }, []);
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
@@ -20,7 +27,18 @@ export default function LoginPage() {
     setLoading(true);
 
     try {
+      if (mfaToken) {
+        const response = await authService.verifyMFA(mfaToken, mfaCode);
+        authService.saveAuth(response);
+        router.push('/dashboard');
+        return;
+      }
+
       const response = await authService.login(formData);
+      if ('mfa_required' in response) {
+        setMfaToken(response.mfa_token);
+        return;
+      }
       authService.saveAuth(response);
       router.push('/dashboard');
     } catch (err: any) {
@@ -82,16 +100,51 @@ export default function LoginPage() {
             />
           </div>
 
+          {/* Two-factor Code Field */}
+          {mfaToken && (
+            <div>
+              <label htmlFor="mfaCode" className="block text-sm font-medium text-gray-700 mb-2">
+                Authentication Code
+              </label>
+              <input
+                id="mfaCode"
+                type="text"
+                inputMode="numeric"
+                autoComplete="one-time-code"
+                required
+                value={mfaCode}
+                onChange={(e) => setMfaCode(e.target.value)}
+                className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none transition"
+                placeholder="123456 or recovery code"
+              />
+            </div>
+          )}
+
           {/* Submit Button */}
           <button
             type="submit"
             disabled={loading}
             className="w-full bg-blue-600 text-white py-3 rounded-lg font-semibold hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-blue-500 focus:ring-offset-2 transition disabled:opacity-50 disabled:cursor-not-allowed"
           >
-            {loading ? 'Signing in...' : 'Sign In'}
+            {loading ? 'Signing in...' : mfaToken ? 'Verify' : 'Sign In'}
           </button>
         </form>
 
+        {/* Identity Providers */}
+        {providers.length > 0 && !mfaToken && (
+          <div className="mt-6 space-y-3">
+            {providers.map((provider) => (
+              <a
+                key={provider}
+                href={authService.oidcLoginURL(provider)}
+                className="block w-full text-center border border-gray-300 py-3 rounded-lg font-semibold text-gray-700 hover:bg-gray-50 transition capitalize"
+              >
+                Continue with {provider}
+              </a>
+            ))}
+          </div>
+        )}
+
         {/* Divider */}
         <div className="mt-6 text-center">
           <p className="text-gray-600">
@@ -0,0 +1,109 @@
+'use client';
+
+import { useEffect, useState } from 'react';
+import { useRouter } from 'next/navigation';
+import Link from 'next/link';
+import { authService } from '@/lib/auth.service';
+
+const errorMessages: Record<string, string> = {
+  email_not_verified:
+    'Your email address is not verified, either by your identity provider or on the existing account with this email. Verify it, then try again.',
+  identity_in_use: 'That account is already linked to another user.',
+  invalid_state: 'The sign-in request expired. Please try again.',
+};
+
+export default function OIDCCallbackPage() {
+  const router = useRouter();
+  const [mfaToken, setMfaToken] = useState('');
+  const [mfaCode, setMfaCode] = useState('');
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.search);
+    const code = params.get('code');
+    const providerError = params.get('error');
+
+    if (params.get('linked')) {
+      router.replace('/dashboard');
+      return;
+    }
+    if (providerError || !code) {
+      setError(errorMessages[providerError || ''] || 'Sign-in failed. Please try again.');
+      return;
+    }
+
+    authService
+      .exchangeOIDCCode(code)
+      .then((response) => {
+        if ('mfa_required' in response) {
+          setMfaToken(response.mfa_token);
+          return;
+        }
+        authService.saveAuth(response);
+        router.replace('/dashboard');
+      })
+      .catch((err: any) => setError(err.response?.data?.error || 'Sign-in failed. Please try again.'));
+  }, [router]);
+
+  const handleVerify = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setLoading(true);
+
+    try {
+      const response = await authService.verifyMFA(mfaToken, mfaCode);
+      authService.saveAuth(response);
+      router.replace('/dashboard');
+    } catch (err: any) {
+      setError(err.response?.data?.error || 'Verification failed. Please try again.');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-blue-50 to-indigo-100 px-4">
+      <div className="max-w-md w-full bg-white rounded-2xl shadow-xl p-8">
+        {error ? (
+          <div className="text-center">
+            <div className="mb-4 p-4 bg-red-50 border border-red-200 text-red-700 rounded-lg">
+              {error}
+            </div>
+            <Link href="/login" className="text-blue-600 hover:text-blue-700 font-semibold">
+              Back to sign in
+            </Link>
+          </div>
+        ) : mfaToken ? (
+          <form onSubmit={handleVerify} className="space-y-6">
+            <div>
+              <label htmlFor="mfaCode" className="block text-sm font-medium text-gray-700 mb-2">
+                Authentication Code
+              </label>
+              <input
+                id="mfaCode"
+                type="text"
+                inputMode="numeric"
+                autoComplete="one-time-code"
+                required
+                value={mfaCode}
+                onChange={(e) => setMfaCode(e.target.value)}
+                className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none transition"
+                placeholder="123456 or recovery code"
+              />
+            </div>
+            <button
+              type="submit"
+              disabled={loading}
+              className="w-full bg-blue-600 text-white py-3 rounded-lg font-semibold hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-blue-500 focus:ring-offset-2 transition disabled:opacity-50 disabled:cursor-not-allowed"
+            >
+              {loading ? 'Verifying...' : 'Verify'}
+            </button>
+          </form>
+        ) : (
+          <p className="text-center text-gray-600">Signing you in...</p>
+        )}
+      </div>
+    </div>
+  );
+}
@@ -27,8 +27,8 @@ export default function RegisterPage() {
     }
 
     // Validate password length
-    if (formData.password.length < 6) {
-      setError('Password must be at least 6 characters');
+    if (formData.password.length < 8) {
+      setError('Password must be at least 8 characters');
       return;
     }
 
@@ -112,7 +112,7 @@ export default function RegisterPage() {
               className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none transition"
               placeholder="••••••••"
             />
-            <p className="mt-1 text-sm text-gray-500">Must be at least 6 characters</p>
+            <p className="mt-1 text-sm text-gray-500">At least 8 characters, hard to guess, and not containing your name or email</p>
           </div>
 
           {/* Confirm Password Field */}
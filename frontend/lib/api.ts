@@ -1,10 +1,15 @@
 import axios from 'axios';
 
+// With cookie auth the backend keeps the tokens in HttpOnly cookies and we
+// only echo the CSRF token on every request.
+export const cookieAuth = process.env.NEXT_PUBLIC_AUTH_COOKIES === 'true';
+
 const api = axios.create({
   baseURL: process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/api',
   headers: {
     'Content-Type': 'application/json',
   },
+  withCredentials: cookieAuth,
 });
 
 api.interceptors.request.use(
@@ -13,6 +18,10 @@ api.interceptors.request.use(
     if (token) {
       config.headers.Authorization = `Bearer ${token}`;
     }
+    const csrfToken = localStorage.getItem('csrf_token');
+    if (cookieAuth && csrfToken) {
+      config.headers['X-CSRF-Token'] = csrfToken;
+    }
     return config;
   },
   (error) => {
@@ -20,13 +29,54 @@ api.interceptors.request.use(
   }
 );
 
+let refreshRequest: Promise<string> | null = null;
+
+const refreshAccessToken = async (): Promise<string> => {
+  if (cookieAuth) {
+    const response = await axios.post(`${api.defaults.baseURL}/auth/refresh`, {}, {
+      withCredentials: true,
+      headers: { 'X-CSRF-Token': localStorage.getItem('csrf_token') || '' },
+    });
+    localStorage.setItem('csrf_token', response.data.csrf_token);
+    return '';
+  }
+
+  const refreshToken = localStorage.getItem('refresh_token');
+  if (!refreshToken) {
+    throw new Error('No refresh token');
+  }
+
+  const response = await axios.post(`${api.defaults.baseURL}/auth/refresh`, {
+    refresh_token: refreshToken,
+  });
+  localStorage.setItem('token', response.data.token);
+  localStorage.setItem('refresh_token', response.data.refresh_token);
+  return response.data.token;
+};
+
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      localStorage.removeItem('token');
-      localStorage.removeItem('user');
-      window.location.href = '/login';
+  async (error) => {
+    const original = error.config;
+    if (error.response?.status === 401 && original && !original._retry) {
+      original._retry = true;
+      try {
+        // Share one refresh between concurrent 401s; refresh tokens are single-use.
+        refreshRequest = refreshRequest || refreshAccessToken();
+        const token = await refreshRequest;
+        if (token) {
+          original.headers.Authorization = `Bearer ${token}`;
+        }
+        return api(original);
+      } catch {
+        localStorage.removeItem('token');
+        localStorage.removeItem('refresh_token');
+        localStorage.removeItem('csrf_token');
+        localStorage.removeItem('user');
+        window.location.href = '/login';
+      } finally {
+        refreshRequest = null;
+      }
     }
     return Promise.reject(error);
   }
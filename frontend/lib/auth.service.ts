@@ -1,4 +1,5 @@
-import api from './api';
+import api, { cookieAuth } from './api';
+import { postWithProofOfWork } from './pow';
 
 export interface RegisterData {
   name: string;
@@ -15,22 +16,61 @@ export interface User {
   id: number;
   name: string;
   email: string;
+  role: 'user' | 'admin';
+  email_verified_at: string | null;
   created_at: string;
 }
 
+// token and refresh_token are omitted when the backend uses cookie auth.
 export interface AuthResponse {
   user: User;
-  token: string;
+  token?: string;
+  refresh_token?: string;
+  csrf_token?: string;
+  expires_in: number;
+}
+
+export interface MFAChallenge {
+  mfa_required: true;
+  mfa_token: string;
+  expires_in: number;
 }
 
 export const authService = {
   register: async (data: RegisterData): Promise<AuthResponse> => {
-    const response = await api.post('/auth/register', data);
+    return postWithProofOfWork<AuthResponse>('/auth/register', data);
+  },
+
+  login: async (data: LoginData): Promise<AuthResponse | MFAChallenge> => {
+    return postWithProofOfWork<AuthResponse | MFAChallenge>('/auth/login', data);
+  },
+
+  verifyMFA: async (mfaToken: string, code: string): Promise<AuthResponse> => {
+    const response = await api.post('/auth/mfa/verify', { mfa_token: mfaToken, code });
     return response.data;
   },
 
-  login: async (data: LoginData): Promise<AuthResponse> => {
-    const response = await api.post('/auth/login', data);
+  requestMagicLink: async (email: string): Promise<void> => {
+    await api.post('/auth/magic-link', { email });
+  },
+
+  verifyMagicLink: async (token: string): Promise<AuthResponse | MFAChallenge> => {
+    const response = await api.post('/auth/magic-link/verify', { token });
+    return response.data;
+  },
+
+  getOIDCProviders: async (): Promise<string[]> => {
+    const response = await api.get('/auth/oidc/providers');
+    return response.data.providers;
+  },
+
+  // The browser is sent here; the backend redirects on to the provider.
+  oidcLoginURL: (provider: string): string => {
+    return `${api.defaults.baseURL}/auth/oidc/${encodeURIComponent(provider)}`;
+  },
+
+  exchangeOIDCCode: async (code: string): Promise<AuthResponse | MFAChallenge> => {
+    const response = await api.post('/auth/oidc/exchange', { code });
     return response.data;
   },
 
@@ -39,14 +79,30 @@ export const authService = {
     return response.data;
   },
 
-  logout: () => {
+  logout: async (all = false) => {
+    try {
+      await api.post('/auth/logout', {
+        refresh_token: localStorage.getItem('refresh_token') || undefined,
+        all,
+      });
+    } catch {
+      // The session is cleared locally either way.
+    }
     localStorage.removeItem('token');
+    localStorage.removeItem('refresh_token');
+    localStorage.removeItem('csrf_token');
     localStorage.removeItem('user');
     window.location.href = '/login';
   },
 
   saveAuth: (data: AuthResponse) => {
-    localStorage.setItem('token', data.token);
+    if (data.token && data.refresh_token) {
+      localStorage.setItem('token', data.token);
+      localStorage.setItem('refresh_token', data.refresh_token);
+    }
+    if (data.csrf_token) {
+      localStorage.setItem('csrf_token', data.csrf_token);
+    }
     localStorage.setItem('user', JSON.stringify(data.user));
   },
 
@@ -56,6 +112,9 @@ export const authService = {
   },
 
   isAuthenticated: (): boolean => {
+    if (cookieAuth) {
+      return !!localStorage.getItem('user');
+    }
     return !!localStorage.getItem('token');
   },
 };
\ No newline at end of file
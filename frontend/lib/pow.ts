@@ -0,0 +1,51 @@
+import axios from 'axios';
+import api from './api';
+
+interface ProofOfWorkChallenge {
+  challenge: string;
+  difficulty: number;
+  algorithm: 'sha256';
+  expires_at: string;
+}
+
+const leadingZeroBits = (hash: Uint8Array): number => {
+  let count = 0;
+  for (const byte of hash) {
+    if (byte !== 0) {
+      return count + Math.clz32(byte) - 24;
+    }
+    count += 8;
+  }
+  return count;
+};
+
+// Finds a nonce such that SHA-256(challenge + ":" + nonce) starts with
+// `difficulty` zero bits.
+export const solveChallenge = async (challenge: string, difficulty: number): Promise<string> => {
+  const encoder = new TextEncoder();
+  for (let i = 0; ; i++) {
+    const nonce = i.toString();
+    const hash = await crypto.subtle.digest('SHA-256', encoder.encode(`${challenge}:${nonce}`));
+    if (leadingZeroBits(new Uint8Array(hash)) >= difficulty) {
+      return nonce;
+    }
+  }
+};
+
+// Posts data and, when the backend asks for a proof of work (428), solves a
+// fresh challenge and sends the request again.
+export const postWithProofOfWork = async <T>(url: string, data: object): Promise<T> => {
+  try {
+    const response = await api.post(url, data);
+    return response.data;
+  } catch (error) {
+    if (!axios.isAxiosError(error) || error.response?.status !== 428) {
+      throw error;
+    }
+  }
+
+  const { data: pow } = await api.get<ProofOfWorkChallenge>('/auth/pow/challenge');
+  const nonce = await solveChallenge(pow.challenge, pow.difficulty);
+  const response = await api.post(url, { ...data, pow_challenge: pow.challenge, pow_nonce: nonce });
+  return response.data;
+};